@@ -2,6 +2,7 @@ package run
 
 import (
 	"cmp"
+	"errors"
 	"slices"
 	"strings"
 )
@@ -11,9 +12,15 @@ type Option interface {
 	description() string
 	seeAlso() []*Command
 	setSeeAlso(cmds ...*Command)
+	source() Source
+	setSource(Source)
+	reset()
 	parseDefault(string) error
 	okValues() []string
 	okPrefix() string
+	choices() []Choice
+	completer() Completer
+	sensitive() bool
 	debug() string
 }
 
@@ -23,15 +30,74 @@ type Flag struct {
 	string        string
 	option        Option
 	hint          string
+	group         string
+	persistent    bool
 	defaultString string
+	defaultFunc   func(Context) (string, error)
+	defaultText   string
 	defaultSet    bool
 	valueSet      bool
+	env           string
+	required      bool
+	hidden        bool
 }
 
 // Default specifies a value that will be supplied for an unprovided flag.
 func (f Flag) Default(string string) Flag {
 	f.defaultString = string
 	f.defaultSet = true
+	f.defaultFunc = nil
+	return f
+}
+
+// DefaultFunc specifies a function to compute the value that will be supplied for an unprovided flag.
+// It is only called if the flag was not provided.
+func (f Flag) DefaultFunc(fn func(Context) (string, error)) Flag {
+	f.defaultFunc = fn
+	f.defaultString = ""
+	f.defaultSet = false
+	return f
+}
+
+// Env specifies an environment variable that supplies the value for an unprovided flag.
+// It takes precedence over any default, and an empty variable is treated as unset.
+// For flags that take no value, any non-empty value provides the flag.
+func (f Flag) Env(name string) Flag {
+	f.env = name
+	return f
+}
+
+// DefaultText specifies how help describes the default value.
+// This is useful for computed defaults, or defaults that are better described than shown.
+func (f Flag) DefaultText(text string) Flag {
+	f.defaultText = text
+	return f
+}
+
+// Group places the flag in a titled section of help, rather than under Flags.
+// Sections are listed in the order their first flag was declared.
+func (f Flag) Group(title string) Flag {
+	f.group = title
+	return f
+}
+
+// Required makes it an error to omit the flag, unless its environment variable or a default supplies it.
+func (f Flag) Required() Flag {
+	f.required = true
+	return f
+}
+
+// Hidden omits the flag from help, generated documentation, and completion.
+// It is still accepted on the command line.
+func (f Flag) Hidden() Flag {
+	f.hidden = true
+	return f
+}
+
+// Persistent makes the flag available to all subcommands of its command.
+// Flags are otherwise only accepted by the command that declares them.
+func (f Flag) Persistent() Flag {
+	f.persistent = true
 	return f
 }
 
@@ -48,12 +114,27 @@ func (c commands) searchString(index int, s string) int { return cmp.Compare(c[i
 
 // Arg represents a positional option for a Command.
 type Arg struct {
-	option Option
-	name   string
+	option        Option
+	name          string
+	defaultString string
+	defaultSet    bool
 }
 
 func (Arg) applyCommand(*Command) error { return errNotGrouped{} }
 
+// Default specifies a value that will be supplied for an unprovided argument, making it optional.
+// Only trailing arguments can be omitted, so all arguments after it should also have defaults.
+func (a Arg) Default(string string) Arg {
+	a.defaultString = string
+	a.defaultSet = true
+	return a
+}
+
+// Name returns the argument's name, as shown in usage like <name>.
+func (a Arg) Name() string {
+	return a.name
+}
+
 func (a Arg) can(dashArg string) (ok bool) {
 	if slices.Contains(a.option.okValues(), dashArg) {
 		return true
@@ -63,18 +144,100 @@ func (a Arg) can(dashArg string) (ok bool) {
 	return nonDash >= 0 && strings.HasPrefix(a.option.okPrefix(), dashArg[:nonDash])
 }
 
+// name returns the flag's preferred name for messages.
+func (f *Flag) name() string {
+	if f.string != "" {
+		return "--" + f.string
+	}
+	return "-" + string(f.rune)
+}
+
+// describe returns the flag's names and value placeholder for help.
+// If inline is set, a known default is shown in place of the placeholder.
+func (f *Flag) describe(inline bool) string {
+	var names []string
+	if f.rune != 0 {
+		names = append(names, "-"+string(f.rune))
+	}
+	if f.string != "" {
+		names = append(names, "--"+f.string)
+	}
+	name := strings.Join(names, ", ")
+	if def, ok := f.inlineDefault(inline); ok {
+		name += "=" + def
+	} else if c := inlineChoices(f.choices()); c != "" {
+		name += "=" + c
+	} else if p := f.hint; p != "" {
+		name += "=" + p
+	}
+	return name
+}
+
+// inlineDefault returns the default that describe shows, if any.
+// Defaults of flags with inline choices are only shown if described by DefaultText.
+func (f *Flag) inlineDefault(inline bool) (string, bool) {
+	switch {
+	case !inline:
+		return "", false
+	case f.defaultText != "":
+		return f.defaultText, true
+	case f.defaultSet && inlineChoices(f.choices()) == "":
+		return redact(f.defaultString, f.sensitive()), true
+	}
+	return "", false
+}
+
+// defaultDesc returns how help describes the flag's default, if it is known.
+func (f *Flag) defaultDesc() string {
+	if f.defaultText != "" || !f.defaultSet {
+		return f.defaultText
+	}
+	return redact(f.defaultString, f.sensitive())
+}
+
+// sensitive reports whether the flag's value should not be shown.
+func (f *Flag) sensitive() bool {
+	return f.option != nil && f.option.sensitive()
+}
+
+// choices returns the names the flag accepts, if limited.
+func (f *Flag) choices() []Choice {
+	if f.option == nil {
+		return nil
+	}
+	return f.option.choices()
+}
+
+// listedChoices returns the flag's choices, unless describe shows them inline.
+func (f *Flag) listedChoices(inline bool) []Choice {
+	if _, ok := f.inlineDefault(inline); !ok && inlineChoices(f.choices()) != "" {
+		return nil
+	}
+	return f.choices()
+}
+
+// describe returns the argument's placeholder for usage, bracketed if it is optional.
 func (a Arg) describe() string {
 	desc := "<" + a.name + ">"
 	if _, ok := a.option.(valuesParser); ok {
 		desc += " ..."
 	}
+	if a.defaultSet {
+		desc = "[" + desc + "]"
+	}
 	return desc
 }
 
 // Cmd creates a command and applies options.
 func Cmd(name, desc string, opts ...CmdOption) (*Command, error) {
 	cmd := &Command{name: name, desc: desc}
-	return cmd, applyOpts(cmd, opts)
+	if err := applyOpts(cmd, opts); err != nil {
+		return cmd, err
+	}
+	if cmd.version != nil {
+		return cmd, errors.New(name + ": Version applies only to an application")
+	}
+	return cmd, nil
 }
 
 // MustCmd creates a command, applies options, and panics on error.
@@ -91,6 +254,7 @@ func MustCmd(name, desc string, opts ...CmdOption) *Command {
 // An application starts with an implicit root command, to which other "sub" commands can be added.
 type Command struct {
 	name, desc, detail string
+	group              string // section for this command in its parent's help
 
 	parent *Command
 	cmds   commands
@@ -101,8 +265,15 @@ type Command struct {
 	flookup func(arg string) (index, rem int) // returns index in flags of matching flag (or -1), index in arg after = (or 0)
 
 	handler  Handler
+	uses     []Middleware
+	befores  []func(Context) error
+	afters   []func(Context, error) error
 	noHelp   bool // don't offer -h|--help for this command
 	unlisted bool // don't list this command in its parents help
+	topics   []helpTopic
+	examples []example
+	version  *VersionInfo // set by Version on the application
+	about    *Command     // the command described by a pseudo-command such as help
 }
 
 // CommandName returns the hierarchical name for a command.
@@ -131,6 +302,17 @@ func (c *Command) Name() string {
 	return strings.Join(parts, ".")
 }
 
+// path returns the names of the commands from the application to c.
+func (c *Command) path() []string {
+	parts := []string{c.name}
+	for c.parent != nil {
+		c = c.parent
+		parts = append(parts, c.name)
+	}
+	slices.Reverse(parts)
+	return parts
+}
+
 // SetDetails sets extra help information for a Command.
 // Attempting to set details more than once causes an error.
 func (c *Command) SetDetails(detail string) error {
@@ -166,6 +348,25 @@ func (c *Command) SetHandler(handler Handler) error {
 	return nil
 }
 
+// Use adds middleware that wraps the handler of this Command and its subcommands.
+// Middleware of parent commands wraps that of their children, and earlier middleware wraps later.
+func (c *Command) Use(mw ...Middleware) {
+	c.uses = append(c.uses, mw...)
+}
+
+// Before adds a hook that runs before the handler of this Command and its subcommands.
+// Hooks run from the root command to the selected one; if any fails, the handler does not run.
+func (c *Command) Before(hook func(Context) error) {
+	c.befores = append(c.befores, hook)
+}
+
+// After adds a hook that runs after the handler of this Command and its subcommands.
+// Hooks run from the selected command to the root, even if the handler or a Before hook failed.
+// Each receives the error so far, and returns the error to pass on.
+func (c *Command) After(hook func(Context, error) error) {
+	c.afters = append(c.afters, hook)
+}
+
 // Flags returns a copy of the flags previously set.
 func (c *Command) Flags() []Flag {
 	if c == nil {
@@ -268,6 +469,9 @@ func (c *Command) SetCommands(cmds ...*Command) error {
 		return wrap(ErrRedefined, c.name+" commands")
 	}
 	c.cmds = cmds
+	for _, sub := range cmds {
+		sub.parent = c
+	}
 
 	stringIndex := make([]int, 0, len(cmds))
 	for i, sub := range cmds {
@@ -306,6 +510,19 @@ func (c *Command) lookupFlag(arg string) (index, rem int) {
 	return c.flookup(arg)
 }
 
+// inheritedFlags returns the persistent flags of c's ancestors, nearest first.
+func (c *Command) inheritedFlags() []*Flag {
+	var flags []*Flag
+	for cmd := c.parent; cmd != nil; cmd = cmd.parent {
+		for i := range cmd.flags {
+			if cmd.flags[i].persistent {
+				flags = append(flags, &cmd.flags[i])
+			}
+		}
+	}
+	return flags
+}
+
 func (c *Command) lookupHandler() (Handler, error) {
 	// non-leaf commands may have or omit a handler.
 	if c.handler == nil {
@@ -314,7 +531,42 @@ func (c *Command) lookupHandler() (Handler, error) {
 			return nil, noHandlerError{ec(c)}
 		}
 		// it's the user's mistake error to select a command with an omitted handler.
-		return nil, missingCmdError{ec(c)}
+		return nil, missingCmdError(c)
 	}
 	return c.handler, nil
 }
+
+// hookHandler wraps handler with the Before and After hooks of c and its ancestors.
+func (c *Command) hookHandler(handler Handler) Handler {
+	var chain []*Command
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		chain = append(chain, cmd)
+	}
+	return func(ctx Context) (err error) {
+		defer func() {
+			for _, cmd := range chain {
+				for _, after := range cmd.afters {
+					err = after(ctx, err)
+				}
+			}
+		}()
+		for i := len(chain) - 1; i >= 0; i-- {
+			for _, before := range chain[i].befores {
+				if err := before(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return handler(ctx)
+	}
+}
+
+// wrapHandler applies the middleware of c and its ancestors to handler.
+func (c *Command) wrapHandler(handler Handler) Handler {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		for i := len(cmd.uses) - 1; i >= 0; i-- {
+			handler = cmd.uses[i](handler)
+		}
+	}
+	return handler
+}
@@ -21,6 +21,58 @@ func DetailsFor(detail string, opts ...Option) CmdOption {
 	})
 }
 
+// Group places a Command in a titled section of its parent's help, rather than under Commands.
+// Sections are listed in the order their first command was declared.
+func Group(title string) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.group = title
+		return nil
+	})
+}
+
+// Use adds middleware that wraps the handler of a Command and its subcommands.
+func Use(mw ...Middleware) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.Use(mw...)
+		return nil
+	})
+}
+
+// Before adds a hook that runs before the handler of a Command and its subcommands.
+func Before(hook func(Context) error) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.Before(hook)
+		return nil
+	})
+}
+
+// After adds a hook that runs after the handler of a Command and its subcommands.
+func After(hook func(Context, error) error) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.After(hook)
+		return nil
+	})
+}
+
+// Unlisted omits a Command from its parent's help and generated documentation.
+// It can still be run by name.
+func Unlisted() CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.unlisted = true
+		return nil
+	})
+}
+
+// HelpTopic adds a topic, such as "environment", to the help of a Command.
+// Topics are listed after its subcommands, and text is shown by "app help [command...] <topic>".
+// The first line of text describes the topic in the list.
+func HelpTopic(name, text string) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.topics = append(cmd.topics, helpTopic{name, text})
+		return nil
+	})
+}
+
 func NoHelp() CmdOption {
 	return cmdOptionFunc(func(cmd *Command) error {
 		cmd.noHelp = true
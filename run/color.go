@@ -0,0 +1,107 @@
+package run
+
+import (
+	"slices"
+	"strings"
+)
+
+// ColorMode chooses when help and errors are styled with ANSI escape sequences.
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"   // style output written to a terminal, unless $NO_COLOR is set
+	ColorAlways ColorMode = "always" // always style output
+	ColorNever  ColorMode = "never"  // never style output
+)
+
+// Theme holds the SGR parameters, such as "1;31", used to style each part of help and errors.
+// Empty fields are left unstyled.
+type Theme struct {
+	Heading     string // Heading styles "Usage:" and section titles.
+	Flag        string // Flag styles flag names.
+	Placeholder string // Placeholder styles flag defaults and value hints.
+	Error       string // Error styles the "error:" prefix.
+}
+
+// DefaultTheme is used for styled output unless SetTheme is called.
+var DefaultTheme = Theme{Heading: "1", Flag: "36", Placeholder: "33", Error: "1;31"}
+
+// SetColor enables styled help and errors, and adds a --color flag to override mode on the command line.
+// In ColorAuto mode, output is styled when Environ.IsTerminal reports it goes to a terminal.
+// A non-empty $NO_COLOR disables this, and a $CLICOLOR_FORCE other than "0" forces it.
+func (a *Application) SetColor(mode ColorMode) {
+	a.color = mode
+	a.setBuiltin(StringVarOf(&a.color, "color", "When to use color", ColorAuto, ColorAlways, ColorNever).
+		Flags(0, "color", "<when>").Default(string(mode)))
+}
+
+// SetTheme sets the styles used when color is enabled.
+func (a *Application) SetTheme(t Theme) {
+	a.theme = &t
+}
+
+// colorTheme returns the theme to style output to w with, or nil if it should be plain.
+func (a *Application) colorTheme(env Environ, w any) *Theme {
+	switch a.color {
+	case ColorAlways:
+	case ColorAuto:
+		if env.Getenv != nil {
+			if env.Getenv("NO_COLOR") != "" {
+				return nil
+			}
+			if force := env.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+				break
+			}
+		}
+		if env.IsTerminal == nil || !env.IsTerminal(w) {
+			return nil
+		}
+	default: // ColorNever, or SetColor was not called
+		return nil
+	}
+	if a.theme != nil {
+		return a.theme
+	}
+	return &DefaultTheme
+}
+
+// setBuiltin adds flag to those accepted by every command, replacing any with the same long name.
+func (a *Application) setBuiltin(flag Flag) {
+	flags := slices.DeleteFunc(slices.Clone(a.builtin.flags), func(f Flag) bool { return f.string == flag.string })
+	a.builtin.flookup = nil
+	_ = a.builtin.SetFlags(append(flags, flag)...)
+}
+
+// paint wraps s in the SGR sequence for style, unless t is nil or the style is empty.
+func (t *Theme) paint(style, s string) string {
+	if t == nil || style == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + style + "m" + s + "\x1b[0m"
+}
+
+// heading styles a heading such as "Flags:".
+func (t *Theme) heading(s string) string {
+	if t == nil {
+		return s
+	}
+	return t.paint(t.Heading, s)
+}
+
+// flag styles a flag name column such as "-v, --verbose=<level>".
+func (t *Theme) flag(s string) string {
+	if t == nil {
+		return s
+	}
+	names, value, hasValue := strings.Cut(s, "=")
+	lead := len(names) - len(strings.TrimLeft(names, " "))
+	parts := strings.Split(names[lead:], ", ")
+	for i := range parts {
+		parts[i] = t.paint(t.Flag, parts[i])
+	}
+	s = names[:lead] + strings.Join(parts, ", ")
+	if hasValue {
+		s += "=" + t.paint(t.Placeholder, value)
+	}
+	return s
+}
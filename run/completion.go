@@ -0,0 +1,372 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompletionCommand returns a command that writes a shell completion script for app.
+// Add it to app with SetCommands, and run it as "app completion bash", "zsh", "fish", or "powershell".
+//
+// The scripts ask the application for candidates by running it as "app __complete <words...>",
+// so they stay in sync with its commands, flags, and allowed values, and can use each option's Completer.
+func CompletionCommand(app *Application) *Command {
+	app.completion = true
+	shell := StringOf("shell", "Shell to write a script for", "bash", "zsh", "fish", "powershell")
+	return MustCmd("completion", "Generate a shell completion script",
+		Details("Write a script that completes "+app.name+" commands, flags, and values.\n\n"+
+			"For example, to load completions in the current bash session:\n\n"+
+			"\tsource <("+app.name+" completion bash)"),
+		shell.Arg("shell"),
+		Handler1(func(ctx Context, shell string) error { return app.WriteCompletion(ctx.Stdout, shell) }, shell),
+	)
+}
+
+// WriteCompletion writes a completion script for shell, which is one of bash, zsh, fish, or powershell.
+func (a *Application) WriteCompletion(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	fn := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, a.name)
+	_, err := io.WriteString(w, strings.NewReplacer("{{name}}", a.name, "{{fn}}", fn).Replace(script))
+	return err
+}
+
+// Completer returns candidates to complete an option's value, given the text typed so far.
+// Candidates that do not start with prefix are ignored. The Context reflects the command line before the value.
+type Completer func(ctx Context, prefix string) []Candidate
+
+// Candidate is a possible completion of a command line word.
+type Candidate struct {
+	Value       string
+	Description string
+	Directive   Directive // Directive adjusts how the shell completes the word.
+	Extensions  []string  // Extensions limit CompleteFiles to names with these suffixes, such as ".go".
+}
+
+// Directive adjusts how a shell completes a word.
+// The directives of all candidates are combined.
+type Directive int
+
+const (
+	NoSpace       Directive = 1 << iota // don't add a space after the completion
+	CompleteFiles                       // also complete file names
+	CompleteDirs                        // also complete directory names
+)
+
+// FileCandidates returns a Candidate that completes file names, limited to those with one of exts if any.
+func FileCandidates(exts ...string) Candidate {
+	return Candidate{Directive: CompleteFiles, Extensions: exts}
+}
+
+// DirCandidates returns a Candidate that completes directory names.
+func DirCandidates() Candidate {
+	return Candidate{Directive: CompleteDirs}
+}
+
+// completeFiles is the default Completer of File options.
+func completeFiles(Context, string) []Candidate { return []Candidate{FileCandidates()} }
+
+// completion records where the word being completed falls in a command line.
+type completion struct {
+	cur    *Command
+	flags  bool   // the word is a flag name
+	flag   *Flag  // the word is a value for flag
+	prefix string // the text before the flag's value in the word, as in --flag=
+	arg    *Arg   // the word is a value for arg
+}
+
+// locate records that word is being completed for cur, having filled carg args.
+func (c *completion) locate(a *Application, cur *Command, carg int, canFlag bool, word string) {
+	c.cur = cur
+	switch {
+	case canFlag && strings.HasPrefix(word, "-"):
+		if name, _, ok := strings.Cut(word, "="); ok {
+			if flag := a.acceptedFlag(cur, name); flag != nil {
+				c.flag, c.prefix = flag, name+"="
+				return
+			}
+		}
+		c.flags = true
+	case carg < len(cur.args):
+		c.arg = &cur.args[carg]
+	}
+}
+
+// completeCommand returns a command that writes completions for the last of words, for the command line before it.
+// Each candidate is written on its own line with a tab before any description,
+// followed by a line of ':', the combined directives, and any extensions, separated by spaces.
+func (a *Application) completeCommand(words []string) *Command {
+	return &Command{name: "__complete", handler: func(ctx Context) error {
+		args := append([]string{ctx.Args[0]}, words...)
+		if len(words) == 0 {
+			args = append(args, "")
+		}
+		var comp completion
+		_, _ = a.parseWith(ctx.Context, ctx.Environ.WithArgs(args), &comp)
+
+		var directive Directive
+		var exts []string
+		for _, c := range a.complete(Context{Context: ctx.Context, Environ: ctx.Environ, Command: comp.cur}, &comp, args[len(args)-1]) {
+			directive |= c.Directive
+			exts = append(exts, c.Extensions...)
+			switch {
+			case c.Value == "":
+			case c.Description != "":
+				fmt.Fprintf(ctx.Stdout, "%s\t%s\n", c.Value, c.Description)
+			default:
+				fmt.Fprintln(ctx.Stdout, c.Value)
+			}
+		}
+		_, err := fmt.Fprintln(ctx.Stdout, strings.Join(append([]string{":" + strconv.Itoa(int(directive))}, exts...), " "))
+		return err
+	}}
+}
+
+// complete returns the candidates for word at the position recorded by comp.
+func (a *Application) complete(ctx Context, comp *completion, word string) []Candidate {
+	var cands []Candidate
+	add := func(c Candidate) {
+		if c.Value == "" || strings.HasPrefix(c.Value, word) {
+			cands = append(cands, c)
+		}
+	}
+	addValues := func(opt Option, prefix string) {
+		if complete := opt.completer(); complete != nil {
+			for _, c := range complete(ctx, strings.TrimPrefix(word, prefix)) {
+				if c.Value != "" {
+					c.Value = prefix + c.Value
+				}
+				add(c)
+			}
+			return
+		}
+		for _, c := range opt.choices() {
+			add(Candidate{Value: prefix + c.Name, Description: c.Desc})
+		}
+	}
+
+	cur := comp.cur
+	switch {
+	case cur == nil:
+	case comp.flag != nil:
+		if comp.flag.option != nil {
+			addValues(comp.flag.option, comp.prefix)
+		}
+	case comp.flags:
+		if !cur.noHelp {
+			add(Candidate{Value: "--help", Description: "Show context-sensitive help."})
+		}
+		if cur == &a.Command && a.version != nil {
+			add(Candidate{Value: "--version", Description: "Show version information."})
+		}
+		for _, flag := range a.acceptedFlags(cur) {
+			if !flag.hidden {
+				add(Candidate{Value: flag.name(), Description: flag.option.description()})
+			}
+		}
+	case comp.arg != nil:
+		addValues(comp.arg.option, "")
+	default:
+		for _, sub := range cur.cmds {
+			if !sub.unlisted {
+				add(Candidate{Value: sub.name, Description: sub.desc})
+			}
+		}
+		if cur == &a.Command && a.offersHelpCommand() && cur.lookupCmd("help") < 0 {
+			add(Candidate{Value: "help", Description: "Show help for a command or topic"})
+		}
+	}
+	return cands
+}
+
+// acceptedFlags returns the flags accepted by cur: its own, its ancestors' persistent flags, then the application's built-in flags.
+func (a *Application) acceptedFlags(cur *Command) []*Flag {
+	flags := make([]*Flag, 0, len(cur.flags))
+	for i := range cur.flags {
+		flags = append(flags, &cur.flags[i])
+	}
+	flags = append(flags, cur.inheritedFlags()...)
+	for i := range a.builtin.flags {
+		flags = append(flags, &a.builtin.flags[i])
+	}
+	return flags
+}
+
+// acceptedFlag returns the flag accepted by cur that arg names, or nil.
+func (a *Application) acceptedFlag(cur *Command, arg string) *Flag {
+	_, flag, _ := a.lookupFlag(cur, arg)
+	return flag
+}
+
+// lookupFlag finds the flag accepted by cur that arg names, the command that declares it (or cur for built-in flags),
+// and the index in arg after any =.
+func (a *Application) lookupFlag(cur *Command, arg string) (owner *Command, flag *Flag, rem int) {
+	for cmd := cur; cmd != nil; cmd = cmd.parent {
+		if idx, rem := cmd.lookupFlag(arg); idx >= 0 && (cmd == cur || cmd.flags[idx].persistent) {
+			return cmd, &cmd.flags[idx], rem
+		}
+	}
+	if idx, rem := a.builtin.lookupFlag(arg); idx >= 0 {
+		return cur, &a.builtin.flags[idx], rem
+	}
+	return nil, nil, 0
+}
+
+// takesValue reports whether flag consumes the following argument.
+func takesValue(flag *Flag) bool {
+	_, isFlag := flag.option.(flagParser)
+	_, isValue := flag.option.(valueParser)
+	return isValue && !isFlag
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{name}}; load with: source <({{name}} completion bash)
+_{{fn}}_complete() {
+    local -a words out
+    local i w
+    # rejoin words bash split at = or :, and unquote simply quoted ones
+    for ((i = 0; i <= COMP_CWORD; i++)); do
+        w="${COMP_WORDS[i]}"
+        if (( i > 0 )) && [[ $w == [=:] || ${COMP_WORDS[i-1]} == [=:] ]]; then
+            words[${#words[@]}-1]+="$w"
+        else
+            [[ $w == \"*\" || $w == \'*\' ]] && w="${w:1:${#w}-2}"
+            words+=("$w")
+        fi
+    done
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    [[ $cur == [=:] ]] && cur=""
+    local prefix="${words[${#words[@]}-1]%"$cur"}"
+    local IFS=$'\n'
+    out=($("${words[0]}" __complete "${words[@]:1}" 2>/dev/null))
+    local n=${#out[@]}
+    (( n )) && [[ ${out[n-1]} == :* ]] || return
+    local directive="${out[n-1]#:}"
+    unset 'out[n-1]'
+    # compopt needs bash 4; older versions complete without its options
+    local compopt=compopt
+    type compopt &>/dev/null || compopt=:
+    local bits="${directive%% *}" exts="" cand ext
+    [[ $directive == *" "* ]] && exts="${directive#* }"
+    COMPREPLY=()
+    for cand in "${out[@]}"; do
+        cand="${cand%%$'\t'*}"
+        cand="${cand#"$prefix"}"
+        COMPREPLY+=("$cand")
+    done
+    (( bits & 1 )) && $compopt -o nospace
+    if (( bits & 2 )); then
+        $compopt -o filenames
+        if [[ -n $exts ]]; then
+            for ext in ${exts// /$'\n'}; do
+                COMPREPLY+=($(compgen -f -X "!*$ext" -- "$cur"))
+            done
+            COMPREPLY+=($(compgen -d -- "$cur"))
+        else
+            COMPREPLY+=($(compgen -f -- "$cur"))
+        fi
+    elif (( bits & 4 )); then
+        $compopt -o filenames
+        COMPREPLY+=($(compgen -d -- "$cur"))
+    fi
+}
+complete -F _{{fn}}_complete {{name}}
+`,
+	"zsh": `#compdef {{name}}
+# zsh completion for {{name}}; load with: source <({{name}} completion zsh)
+_{{fn}}() {
+    local -a out cands exts opts
+    local line value directive bits
+    out=("${(@f)$("${words[1]}" __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    directive="${out[-1]}"
+    [[ $directive == :* ]] || return 1
+    out=("${(@)out[1,-2]}")
+    bits="${${directive%% *}#:}"
+    [[ $directive == *" "* ]] && exts=(${=directive#* })
+    for line in "${out[@]}"; do
+        [[ -z $line ]] && continue
+        value="${line%%$'\t'*}"
+        if [[ $line == *$'\t'* ]]; then
+            cands+=("${value//:/\\:}:${line#*$'\t'}")
+        else
+            cands+=("${value//:/\\:}")
+        fi
+    done
+    (( bits & 1 )) && opts=(-S '')
+    (( ${#cands} )) && _describe -t values '{{name}}' cands "${opts[@]}"
+    if (( bits & 2 )); then
+        if (( ${#exts} )); then
+            _files -g "*(${(j:|:)exts})"
+        else
+            _files
+        fi
+    elif (( bits & 4 )); then
+        _files -/
+    fi
+}
+compdef _{{fn}} {{name}}
+`,
+	"fish": `# fish completion for {{name}}; load with: {{name}} completion fish | source
+function __{{fn}}_complete
+    set -l args (commandline -opc) (commandline -ct)
+    set -l out ($args[1] __complete $args[2..-1] 2>/dev/null)
+    string match -q -- ':*' $out[-1]; or return
+    set -l parts (string split ' ' -- (string sub -s 2 -- $out[-1]))
+    set -e out[-1]
+    printf '%s\n' $out
+    set -l exts $parts[2..-1]
+    if test (math "bitand($parts[1], 2)") -ne 0
+        for f in (__fish_complete_path (commandline -ct))
+            if test (count $exts) -eq 0; or string match -q -- '*/' $f
+                echo $f
+                continue
+            end
+            for ext in $exts
+                if string match -q -- "*$ext" $f
+                    echo $f
+                    break
+                end
+            end
+        end
+    else if test (math "bitand($parts[1], 4)") -ne 0
+        __fish_complete_directories (commandline -ct)
+    end
+end
+complete -c {{name}} -f -a '(__{{fn}}_complete)'
+`,
+	"powershell": `# PowerShell completion for {{name}}; load with: {{name}} completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName '{{name}}' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.StartOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '' }
+    $out = @(& $words[0] __complete @($words | Select-Object -Skip 1) 2>$null)
+    if ($out.Count -eq 0 -or -not $out[-1].StartsWith(':')) { return }
+    $parts = $out[-1].Substring(1).Split(' ')
+    $bits = [int]$parts[0]
+    $exts = @($parts | Select-Object -Skip 1)
+    $out | Select-Object -SkipLast 1 | ForEach-Object {
+        $value, $desc = $_ -split "` + "`" + `t", 2
+        if (-not $desc) { $desc = $value }
+        [System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $desc)
+    }
+    if ($bits -band 6) {
+        Get-ChildItem -Path "$wordToComplete*" -ErrorAction SilentlyContinue | Where-Object {
+            $_.PSIsContainer -or (($bits -band 2) -and ($exts.Count -eq 0 -or $exts -contains $_.Extension))
+        } | ForEach-Object {
+            $path = Resolve-Path -Relative $_.FullName
+            [System.Management.Automation.CompletionResult]::new($path, $path, 'ProviderItem', $path)
+        }
+    }
+}
+`,
+}
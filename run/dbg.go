@@ -2,6 +2,8 @@ package run
 
 import (
 	"fmt"
+	"io"
+	"slices"
 )
 
 // Debug parses command line arguments, printing any resulting error, selected command, and its options.
@@ -12,37 +14,90 @@ func (a *Application) Debug(args ...string) (*Command, error) {
 // DebugEnv parses command line arguments, printing any resulting error, selected command, and its options.
 func (a *Application) DebugEnv(env Environ, args ...string) (*Command, error) {
 	cmd, err := a.Parse(env.WithArgs(append(env.Args[:1:1], args...)))
+	shown := a.redactArgs(args)
 	if err != nil {
-		fmt.Fprintln(env.Stdout, args, "err:", err)
+		fmt.Fprintln(env.Stdout, shown, "err:", err)
 		if e, ok := err.(interface{ Command() *Command }); ok && e.Command() != nil {
-			e.Command().debug(env)
+			e.Command().debug(env.Stdout, false)
 		}
 	} else {
-		fmt.Fprintln(env.Stdout, args)
-		cmd.debug(env)
+		fmt.Fprintln(env.Stdout, shown)
+		cmd.debug(env.Stdout, false)
 		_, err = cmd.lookupHandler()
 	}
 	return cmd, err
 }
 
-func (c *Command) debug(env Environ) {
+// redactArgs returns a copy of args, the command line after the program name,
+// with the values of sensitive flags and positional arguments replaced.
+func (a *Application) redactArgs(args []string) []string {
+	args = slices.Clone(args)
+	cur, carg, canFlag := &a.Command, 0, true
+	for i := 0; i < len(args); i++ {
+		word := args[i]
+		if canFlag && word == "--" {
+			canFlag = false
+			continue
+		}
+		if canFlag {
+			if _, flag, rem := a.lookupFlag(cur, word); flag != nil {
+				switch {
+				case rem > 0:
+					args[i] = word[:rem] + redact(word[rem:], flag.sensitive())
+				case takesValue(flag) && i+1 < len(args):
+					i++
+					args[i] = redact(args[i], flag.sensitive())
+				}
+				continue
+			}
+		}
+		if carg < len(cur.args) {
+			arg := &cur.args[carg]
+			args[i] = redact(word, arg.option.sensitive())
+			if _, many := arg.option.(valuesParser); !many {
+				carg++
+			}
+			continue
+		}
+		if idx := cur.lookupCmd(word); idx >= 0 {
+			sub := cur.cmds[idx]
+			sub.parent = cur
+			cur, carg = sub, 0
+		}
+	}
+	return args
+}
+
+// DumpConfig writes the selected command and every option's value and source to w.
+// It is intended for diagnosing where effective values came from, as with a --debug-config flag.
+func (c Context) DumpConfig(w io.Writer) {
+	c.Command.debug(w, true)
+}
+
+func (c *Command) debug(w io.Writer, sources bool) {
+	describe := func(opt Option) string {
+		if sources {
+			return opt.debug() + " (" + opt.source().String() + ")"
+		}
+		return opt.debug()
+	}
 	if c == nil {
-		fmt.Fprintln(env.Stdout, "  cmd: <nil>")
+		fmt.Fprintln(w, "  cmd: <nil>")
 	} else {
-		fmt.Fprintln(env.Stdout, "  cmd:", c.Name())
+		fmt.Fprintln(w, "  cmd:", c.Name())
 		for cmd, prefix := c, "  flag:"; cmd != nil; cmd, prefix = cmd.parent, "  "+prefix {
 			for _, f := range cmd.Flags() {
-				fmt.Fprintln(env.Stdout, prefix, f.option.debug())
+				fmt.Fprintln(w, prefix, describe(f.option))
 			}
 		}
 		for cmd, prefix := c, "  arg:"; cmd != nil; cmd, prefix = cmd.parent, "  "+prefix {
 			for _, a := range cmd.Args() {
-				fmt.Fprintln(env.Stdout, prefix, a.option.debug())
+				fmt.Fprintln(w, prefix, describe(a.option))
 			}
 		}
 	}
 }
 
 func (o *flagOnly[T]) debug() string { return o.name + "=" + fmt.Sprint(*o.value) }
-func (o *option[T]) debug() string   { return o.name + "=" + fmt.Sprint(*o.value) }
-func (o *options[T]) debug() string  { return o.name + "=" + fmt.Sprint(*o.value) }
+func (o *option[T]) debug() string   { return o.name + "=" + redact(fmt.Sprint(*o.value), o.secret) }
+func (o *options[T]) debug() string  { return o.name + "=" + redact(fmt.Sprint(*o.value), o.secret) }
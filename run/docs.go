@@ -0,0 +1,193 @@
+package run
+
+import (
+	"bytes"
+	"go/doc/comment"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenMarkdown writes a Markdown page for each listed command in app into dir, and an index.md linking them.
+// Pages are named after the command path, such as app-remote-add.md.
+func (a *Application) GenMarkdown(dir string) error {
+	return a.genDocs(dir, ".md", a.WriteMarkdown, a.writeMarkdownIndex)
+}
+
+// GenHTML writes an HTML page for each listed command in app into dir, and an index.html linking them.
+// Pages are named after the command path, such as app-remote-add.html.
+func (a *Application) GenHTML(dir string) error {
+	return a.genDocs(dir, ".html", a.WriteHTML, a.writeHTMLIndex)
+}
+
+func (a *Application) genDocs(dir, ext string, page func(io.Writer, *Command) error, index func(io.Writer) error) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	write := func(name string, fn func(io.Writer) error) error {
+		var buf bytes.Buffer
+		if err := fn(&buf); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, name+ext), buf.Bytes(), 0o644)
+	}
+	err := a.walk(func(cmd *Command) error {
+		return write(manName(cmd), func(w io.Writer) error { return page(w, cmd) })
+	})
+	if err != nil {
+		return err
+	}
+	return write("index", index)
+}
+
+// WriteMarkdown writes a Markdown page for cmd to w, linking to the pages written by GenMarkdown.
+func (a *Application) WriteMarkdown(w io.Writer, cmd *Command) error {
+	help := a.Help(cmd)
+	var b bytes.Buffer
+	b.WriteString("# " + mdEscape(strings.Join(cmd.path(), " ")) + "\n\n")
+	if help.Description != "" {
+		b.WriteString(mdEscape(help.Description) + "\n\n")
+	}
+	b.WriteString("```\n" + strings.Join(help.Usage, " ") + "\n```\n\n")
+	if help.Details != nil {
+		b.Write((&comment.Printer{HeadingLevel: 2}).Markdown(help.Details))
+		b.WriteString("\n")
+	}
+	for _, sec := range help.Sections {
+		b.WriteString("## " + sec.Title + "\n\n")
+		for _, e := range sec.Entries {
+			name := "`" + e.Name + "`"
+			if e.Command != nil {
+				name = "[" + name + "](" + manName(e.Command) + ".md)"
+			}
+			if desc := withTrailer(e); desc != "" {
+				name += ": " + mdEscape(desc)
+			}
+			b.WriteString("- " + name + "\n")
+			for _, c := range e.Choices {
+				b.WriteString("  - `" + c.Name + "`")
+				if c.Desc != "" {
+					b.WriteString(": " + mdEscape(c.Desc))
+				}
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	if len(help.Examples) > 0 {
+		b.WriteString("## Examples\n\n")
+		for _, ex := range help.Examples {
+			if ex.Explanation != "" {
+				b.WriteString(mdEscape(ex.Explanation) + "\n\n")
+			}
+			b.WriteString("```\n" + ex.Invocation + "\n```\n\n")
+		}
+	}
+	if len(help.SeeAlso) > 0 {
+		b.WriteString("## See Also\n\n")
+		for _, also := range help.SeeAlso {
+			b.WriteString("- [" + mdEscape(strings.Join(also.path(), " ")) + "](" + manName(also) + ".md)\n")
+		}
+		b.WriteString("\n")
+	}
+	_, err := w.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return err
+}
+
+func (a *Application) writeMarkdownIndex(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString("# " + mdEscape(a.name) + "\n\n")
+	if a.desc != "" {
+		b.WriteString(mdEscape(a.desc) + "\n\n")
+	}
+	_ = a.walk(func(cmd *Command) error {
+		b.WriteString("- [" + mdEscape(strings.Join(cmd.path(), " ")) + "](" + manName(cmd) + ".md)")
+		if cmd.desc != "" {
+			b.WriteString(": " + mdEscape(cmd.desc))
+		}
+		b.WriteString("\n")
+		return nil
+	})
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+)
+
+func mdEscape(s string) string { return mdEscaper.Replace(s) }
+
+// WriteHTML writes an HTML fragment for cmd to w, linking to the pages written by GenHTML.
+func (a *Application) WriteHTML(w io.Writer, cmd *Command) error {
+	help := a.Help(cmd)
+	var b bytes.Buffer
+	b.WriteString("<h1>" + html.EscapeString(strings.Join(cmd.path(), " ")) + "</h1>\n")
+	if help.Description != "" {
+		b.WriteString("<p>" + html.EscapeString(help.Description) + "</p>\n")
+	}
+	b.WriteString("<pre>" + html.EscapeString(strings.Join(help.Usage, " ")) + "</pre>\n")
+	if help.Details != nil {
+		b.Write((&comment.Printer{HeadingLevel: 2}).HTML(help.Details))
+	}
+	for _, sec := range help.Sections {
+		b.WriteString("<h2>" + html.EscapeString(sec.Title) + "</h2>\n<dl>\n")
+		for _, e := range sec.Entries {
+			name := "<code>" + html.EscapeString(e.Name) + "</code>"
+			if e.Command != nil {
+				name = `<a href="` + manName(e.Command) + `.html">` + name + "</a>"
+			}
+			b.WriteString("<dt>" + name + "</dt><dd>" + html.EscapeString(withTrailer(e)))
+			if len(e.Choices) > 0 {
+				b.WriteString("\n<dl>\n")
+				for _, c := range e.Choices {
+					b.WriteString("<dt><code>" + html.EscapeString(c.Name) + "</code></dt><dd>" + html.EscapeString(c.Desc) + "</dd>\n")
+				}
+				b.WriteString("</dl>\n")
+			}
+			b.WriteString("</dd>\n")
+		}
+		b.WriteString("</dl>\n")
+	}
+	if len(help.Examples) > 0 {
+		b.WriteString("<h2>Examples</h2>\n")
+		for _, ex := range help.Examples {
+			if ex.Explanation != "" {
+				b.WriteString("<p>" + html.EscapeString(ex.Explanation) + "</p>\n")
+			}
+			b.WriteString("<pre>" + html.EscapeString(ex.Invocation) + "</pre>\n")
+		}
+	}
+	if len(help.SeeAlso) > 0 {
+		b.WriteString("<h2>See Also</h2>\n<ul>\n")
+		for _, also := range help.SeeAlso {
+			b.WriteString(`<li><a href="` + manName(also) + `.html">` + html.EscapeString(strings.Join(also.path(), " ")) + "</a></li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (a *Application) writeHTMLIndex(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString("<h1>" + html.EscapeString(a.name) + "</h1>\n")
+	if a.desc != "" {
+		b.WriteString("<p>" + html.EscapeString(a.desc) + "</p>\n")
+	}
+	b.WriteString("<ul>\n")
+	_ = a.walk(func(cmd *Command) error {
+		b.WriteString(`<li><a href="` + manName(cmd) + `.html">` + html.EscapeString(strings.Join(cmd.path(), " ")) + "</a>")
+		if cmd.desc != "" {
+			b.WriteString(": " + html.EscapeString(cmd.desc))
+		}
+		b.WriteString("</li>\n")
+		return nil
+	})
+	b.WriteString("</ul>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
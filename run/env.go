@@ -3,6 +3,7 @@ package run
 import (
 	"io"
 	"os"
+	"os/signal"
 )
 
 // DefaultEnviron returns an Environ that works like the os package.
@@ -18,6 +19,11 @@ type Environ struct {
 	Stderr    io.Writer
 	Getenv    func(string) string
 	LookupEnv func(string) (string, bool)
+	Signals   func(...os.Signal) (<-chan os.Signal, func()) // Signals relays the signals until the returned func is called.
+	Exit      func(int)
+
+	TerminalWidth func(io.Writer) int // TerminalWidth reports the columns of the terminal a writer displays on, or 0.
+	IsTerminal    func(any) bool      // IsTerminal reports whether a reader or writer, such as Stdout, is a terminal.
 }
 
 // WithArgs overrides Args.
@@ -59,6 +65,30 @@ func (e Environ) WithVariables(env Variables) Environ {
 	return e
 }
 
+// WithSignals overrides Signals to relay from c, such as to simulate signals in tests.
+func (e Environ) WithSignals(c <-chan os.Signal) Environ {
+	e.Signals = func(...os.Signal) (<-chan os.Signal, func()) { return c, func() {} }
+	return e
+}
+
+// WithTerminalWidth overrides TerminalWidth to report width for every writer.
+func (e Environ) WithTerminalWidth(width int) Environ {
+	e.TerminalWidth = func(io.Writer) int { return width }
+	return e
+}
+
+// WithTerminal overrides IsTerminal to report ok for every reader and writer.
+func (e Environ) WithTerminal(ok bool) Environ {
+	e.IsTerminal = func(any) bool { return ok }
+	return e
+}
+
+// WithExit overrides Exit.
+func (e Environ) WithExit(exit func(int)) Environ {
+	e.Exit = exit
+	return e
+}
+
 func (e *Environ) fillDefaults() {
 	if e.Args == nil {
 		e.Args = os.Args
@@ -78,18 +108,47 @@ func (e *Environ) fillDefaults() {
 	if e.LookupEnv == nil {
 		e.LookupEnv = os.LookupEnv
 	}
+	if e.Signals == nil {
+		e.Signals = notifySignals
+	}
+	if e.Exit == nil {
+		e.Exit = os.Exit
+	}
+	if e.TerminalWidth == nil {
+		e.TerminalWidth = terminalWidth
+	}
+	if e.IsTerminal == nil {
+		e.IsTerminal = isTerminal
+	}
+}
+
+// isTerminal reports whether f is an *os.File connected to a terminal.
+// Where terminals cannot be detected, any character device is taken for one.
+func isTerminal(f any) bool {
+	file, ok := f.(*os.File)
+	if !ok {
+		return false
+	}
+	if tty, known := fileIsTerminal(file); known {
+		return tty
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func notifySignals(sig ...os.Signal) (<-chan os.Signal, func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig...)
+	return c, func() { signal.Stop(c) }
 }
 
 // EntryFunc is the recommended type for your entry function.
 type EntryFunc func(Environ) error
 
 // Main runs your entry function and returns an int suitable for [os.Exit].
+// The exit code is chosen by [ExitCode].
 func Main(entry EntryFunc) int {
-	err := entry(DefaultEnviron())
-	if err != nil {
-		return 1
-	}
-	return 0
+	return ExitCode(entry(DefaultEnviron()))
 }
 
 // TestErr runs your entry function and returns its error.
@@ -36,126 +36,191 @@ type NotOneOfError[T any] struct {
 	names []NamedValue[T]
 }
 
+// Error lists the accepted names, and if there are many, one per line with their descriptions.
 func (e NotOneOfError[T]) Error() string {
-	if len(e.names) < 8 {
+	if len(e.names) <= maxInlineChoices {
 		n := make([]string, len(e.names))
 		for i, nam := range e.names {
 			n[i] = strconv.Quote(nam.Name)
 		}
 		return strconv.Quote(e.name) + " not one of " + strings.Join(n, ", ")
 	}
-	return strconv.Quote(e.name) + " unsupported value"
-}
-
-type missingFlagValueError struct {
-	*errCmd
-	flag  *Flag
-	after string
-}
-
-func (e missingFlagValueError) Error() string {
-	hint := cmp.Or(e.flag.hint, "<value>")
-	return e.msg(e.after, "expected "+hint)
-}
-
-type missingCmdError struct {
-	*errCmd
-}
-
-func (e missingCmdError) Error() string {
-	return e.msg("expected <command>")
+	width := 0
+	for _, nam := range e.names {
+		width = max(width, displayWidth(nam.Name))
+	}
+	var b strings.Builder
+	b.WriteString(strconv.Quote(e.name) + " not one of:")
+	for _, nam := range e.names {
+		b.WriteString(strings.TrimRight("\n  "+padRight(nam.Name, width)+"   "+nam.Desc, " "))
+	}
+	return b.String()
 }
 
-type noHandlerError struct{ *errCmd }
+// valueNames returns the rejected name and those accepted.
+func (e NotOneOfError[T]) valueNames() (string, []string) {
+	n := make([]string, len(e.names))
+	for i, nam := range e.names {
+		n[i] = nam.Name
+	}
+	return e.name, n
+}
+
+// ParseErrorKind classifies a ParseError.
+type ParseErrorKind int
+
+const (
+	KindMissingFlagValue ParseErrorKind = iota + 1 // a flag that requires a value was last
+	KindExtraFlagValue                             // a flag that takes no value was given one with =
+	KindExtraFlag                                  // a flag was not recognized
+	KindFlagValue                                  // a flag's value was rejected
+	KindArgValue                                   // a positional argument's value was rejected
+	KindExtraArgs                                  // arguments remained after all positionals were filled
+	KindMissingArgs                                // positional arguments were not provided
+	KindMissingCommand                             // a command without a handler was selected
+	KindBadFlag                                    // a flag's option does not support flags
+	KindBadArg                                     // an arg's option does not support args
+	KindMissingFlag                                // a required flag was not provided
+)
 
-func (e noHandlerError) Error() string {
-	return e.msg("no handler")
+var kindNames = [...]string{
+	KindMissingFlagValue: "missing flag value",
+	KindExtraFlagValue:   "extra flag value",
+	KindExtraFlag:        "extra flag",
+	KindFlagValue:        "flag value",
+	KindArgValue:         "arg value",
+	KindExtraArgs:        "extra args",
+	KindMissingArgs:      "missing args",
+	KindMissingCommand:   "missing command",
+	KindBadFlag:          "bad flag",
+	KindBadArg:           "bad arg",
+	KindMissingFlag:      "missing flag",
+}
+
+func (k ParseErrorKind) String() string {
+	if k > 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "ParseErrorKind(" + strconv.Itoa(int(k)) + ")"
 }
 
-type extraFlagValueError struct {
+// ParseError reports a command line that could not be parsed.
+// Its Command method returns the command being parsed.
+type ParseError struct {
 	*errCmd
-	arg string
-}
+	Kind  ParseErrorKind
+	Flag  *Flag  // Flag is the flag definition involved, if any.
+	Arg   *Arg   // Arg is the arg definition involved, if any. For KindMissingArgs it is the first missing.
+	Token string // Token is the offending argument, or the source of a rejected default. A flag's separate value follows it.
+	Value string // Value is the rejected value for KindFlagValue and KindArgValue, if known, or "***" if sensitive.
+	Index int    // Index is the position of Token in Environ.Args, or -1 if it did not come from Args.
+	Err   error  // Err is the underlying cause, if any.
 
-func (e extraFlagValueError) Error() string {
-	return e.msg("unexpected flag value", e.arg)
+	Extra   []string // Extra lists the unexpected arguments for KindExtraArgs, starting with Token.
+	Missing []*Arg   // Missing lists the positional arguments without defaults that were not provided for KindMissingArgs.
 }
 
-type flagParseError struct {
-	*errCmd
-	flag *Flag
-	val  string
-	err  error
-}
-
-func (e flagParseError) Error() string {
-	return e.msg(e.val, e.err.Error())
-}
+func (e *ParseError) Unwrap() error { return e.Err }
 
-type argParseError struct {
-	*errCmd
-	arg *Arg
-	val string
-	err error
-}
-
-func (e argParseError) Error() string {
-	return e.msg(e.arg.name, e.err.Error())
+// ExitCode reports ExitUsage, or ExitFailure for broken options.
+func (e *ParseError) ExitCode() int {
+	switch e.Kind {
+	case KindBadFlag, KindBadArg:
+		return ExitFailure
+	}
+	return ExitUsage
+}
+
+func (e *ParseError) Error() string {
+	switch e.Kind {
+	case KindMissingFlagValue:
+		hint := cmp.Or(e.Flag.hint, "<value>")
+		return e.msg(e.Token, "expected "+hint)
+	case KindExtraFlagValue:
+		return e.msg("unexpected flag value", e.Token)
+	case KindExtraFlag:
+		return e.msg("unexpected flag", e.Token)
+	case KindFlagValue:
+		return e.msg(e.Token, e.Err.Error())
+	case KindArgValue:
+		return e.msg(e.Arg.name, e.Err.Error())
+	case KindExtraArgs:
+		if len(e.Extra) == 1 {
+			return e.msg("unexpected argument", strconv.Quote(e.Extra[0]))
+		}
+		return e.msg("unexpected arguments", strings.Join(e.Extra, " "))
+	case KindMissingArgs:
+		a := make([]string, len(e.Missing))
+		for i, arg := range e.Missing {
+			a[i] = arg.describe()
+		}
+		if e.Err != nil {
+			return e.msg("expected "+strconv.Quote(strings.Join(a, " ")), e.Err.Error())
+		}
+		return e.msg("expected " + strconv.Quote(strings.Join(a, " ")))
+	case KindMissingCommand:
+		return e.msg("expected <command>")
+	case KindBadFlag:
+		return e.msg("broken flag", e.Token)
+	case KindBadArg:
+		return e.msg("broken argument", e.Token)
+	case KindMissingFlag:
+		if e.Err != nil {
+			return e.msg("missing required flag", e.Token, e.Err.Error())
+		}
+		return e.msg("missing required flag", e.Token)
+	}
+	return e.msg(e.Kind.String())
 }
 
-type extraFlagError struct {
-	*errCmd
-	flag string
+func flagError(kind ParseErrorKind, cmd *Command, flag *Flag, token string, index int, err error) *ParseError {
+	if flag != nil && flag.sensitive() {
+		token = redactToken(token)
+	}
+	return &ParseError{errCmd: ec(cmd), Kind: kind, Flag: flag, Token: token, Index: index, Err: err}
 }
 
-func (e extraFlagError) Error() string {
-	return e.msg("unexpected flag", e.flag)
+// flagValueError reports that flag rejected value, found at token.
+func flagValueError(cmd *Command, flag *Flag, token, value string, index int, err error) *ParseError {
+	e := flagError(KindFlagValue, cmd, flag, token, index, err)
+	e.Value = redact(value, flag.sensitive())
+	return e
 }
 
-type extraArgsError struct {
-	*errCmd
-	args []string
+func argError(kind ParseErrorKind, cmd *Command, arg *Arg, token string, index int, err error) *ParseError {
+	if arg.option != nil && arg.option.sensitive() {
+		token = redacted
+	}
+	e := &ParseError{errCmd: ec(cmd), Kind: kind, Arg: arg, Token: token, Index: index, Err: err}
+	if kind == KindArgValue {
+		e.Value = token
+	}
+	return e
 }
 
-func (e extraArgsError) Error() string {
-	if len(e.args) == 1 {
-		return e.msg("unexpected argument", strconv.Quote(e.args[0]))
-	}
-	return e.msg("unexpected arguments", strings.Join(e.args, " "))
+func extraArgsError(cmd *Command, args []string, index int) *ParseError {
+	return &ParseError{errCmd: ec(cmd), Kind: KindExtraArgs, Token: args[0], Index: index, Extra: args}
 }
 
-type missingArgsError struct {
-	*errCmd
-	args []Arg
+func missingArgsError(cmd *Command, args []*Arg, err error) *ParseError {
+	return &ParseError{errCmd: ec(cmd), Kind: KindMissingArgs, Arg: args[0], Index: -1, Missing: args, Err: err}
 }
 
-func (e missingArgsError) Error() string {
-	a := make([]string, len(e.args))
-	for i, arg := range e.args {
-		a[i] = "<" + arg.name + ">"
-		if _, ok := arg.option.(valuesParser); ok {
-			a[i] += " ..."
-		}
-	}
-	return e.msg("expected " + strconv.Quote(strings.Join(a, " ")))
+func missingCmdError(cmd *Command) *ParseError {
+	return &ParseError{errCmd: ec(cmd), Kind: KindMissingCommand, Index: -1}
 }
 
-type badFlagError struct {
+// handlerError records the command whose handler returned err.
+type handlerError struct {
 	*errCmd
-	flag *Flag
-	at   string
+	err error
 }
 
-func (e badFlagError) Error() string {
-	return e.msg("broken flag", e.at)
-}
+func (e handlerError) Error() string { return e.err.Error() }
+func (e handlerError) Unwrap() error { return e.err }
 
-type badArgError struct {
-	*errCmd
-	arg *Arg
-	at  string
-}
+type noHandlerError struct{ *errCmd }
 
-func (e badArgError) Error() string {
-	return e.msg("broken argument", e.at)
+func (e noHandlerError) Error() string {
+	return e.msg("no handler")
 }
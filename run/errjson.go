@@ -0,0 +1,140 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrorFielder can be implemented by errors to add fields to JSONErrors output.
+type ErrorFielder interface {
+	ErrorFields() map[string]any
+}
+
+// JSONErrors writes err to w as a single-line JSON object, for tools driven by other programs.
+// Use it with [Application.SetErrorFormatter].
+//
+// The object has a kind and message, and where relevant the command path, flag or arg name,
+// offending token and value, and suggested alternatives. Fields from any ErrorFielder in err's tree are added,
+// replacing those with the same name.
+func JSONErrors(w io.Writer, err error) {
+	obj := map[string]any{
+		"kind":    "error",
+		"message": err.Error(),
+	}
+
+	var ce interface{ Command() *Command }
+	if errors.As(err, &ce) && ce.Command() != nil {
+		obj["command"] = strings.Join(ce.Command().path(), " ")
+	}
+
+	var pe *ParseError
+	var he HelpDisabledError
+	var ne noHandlerError
+	switch {
+	case errors.As(err, &pe):
+		obj["kind"] = pe.Kind.String()
+		if pe.Flag != nil {
+			obj["flag"] = pe.Flag.name()
+		}
+		if pe.Arg != nil {
+			obj["arg"] = pe.Arg.name
+		}
+		if pe.Token != "" {
+			obj["token"] = pe.Token
+		}
+		if pe.Value != "" {
+			obj["value"] = pe.Value
+		}
+		if s := pe.suggestions(); len(s) > 0 {
+			obj["suggestions"] = s
+		}
+	case errors.As(err, &he):
+		obj["kind"] = "help disabled"
+	case errors.As(err, &ne):
+		obj["kind"] = "no handler"
+	}
+
+	var fe ErrorFielder
+	if errors.As(err, &fe) {
+		for k, v := range fe.ErrorFields() {
+			obj[k] = v
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(obj) != nil {
+		_ = enc.Encode(map[string]any{"kind": "error", "message": err.Error()})
+	}
+}
+
+// suggestions returns likely alternatives to the offending token.
+func (e *ParseError) suggestions() []string {
+	var names interface{ valueNames() (string, []string) }
+	if errors.As(e.Err, &names) {
+		if (e.Flag != nil && e.Flag.sensitive()) || (e.Arg != nil && e.Arg.option.sensitive()) {
+			return nil // suggestions would hint at the value
+		}
+		return similar(names.valueNames())
+	}
+
+	var candidates []string
+	cmd := e.Command()
+	switch e.Kind {
+	case KindExtraFlag:
+		flags := cmd.inheritedFlags()
+		for i := range cmd.flags {
+			flags = append(flags, &cmd.flags[i])
+		}
+		for _, f := range flags {
+			if f.string != "" {
+				candidates = append(candidates, "--"+f.string)
+			}
+		}
+	case KindExtraArgs, KindMissingCommand:
+		for _, sub := range cmd.cmds {
+			if !sub.unlisted {
+				candidates = append(candidates, sub.name)
+			}
+		}
+		if e.Kind == KindMissingCommand {
+			return candidates
+		}
+	}
+	return similar(e.Token, candidates)
+}
+
+// similar returns the candidates within a small edit distance of s.
+func similar(s string, candidates []string) []string {
+	var near []string
+	for _, c := range candidates {
+		if distance(s, c) <= max(1, len(c)/3) {
+			near = append(near, c)
+		}
+	}
+	return near
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -2,8 +2,15 @@
 package run_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"testing/iotest"
+	"text/template"
 
 	"github.com/mutility/cli/run"
 )
@@ -45,7 +52,8 @@ func ExampleMustApp() {
 func Example_nesting() {
 	try := func(args ...string) {
 		run.MustApp("outer", "",
-			run.Enabler("f", "", false, true).Flag(),
+			run.Enabler("f", "", false, true).Flag().Persistent(),
+			run.Enabler("l", "", false, true).Flag(),
 			run.MustCmd("inner", "",
 				run.Enabler("g", "", false, true).Flag(),
 			),
@@ -58,6 +66,8 @@ func Example_nesting() {
 	try("--g")
 	try("--f", "inner", "--g")
 	try("inner", "--f", "--g")
+	try("--l", "inner")
+	try("inner", "--l")
 
 	// output:
 	// [-h]
@@ -69,17 +79,54 @@ func Example_nesting() {
 	// [--f]
 	//   cmd: outer
 	//   flag: f=true
+	//   flag: l=false
 	// [--g] err: unexpected flag: --g
 	//   cmd: outer
 	//   flag: f=false
+	//   flag: l=false
 	// [--f inner --g]
 	//   cmd: outer.inner
 	//   flag: g=true
 	//     flag: f=true
+	//     flag: l=false
 	// [inner --f --g]
 	//   cmd: outer.inner
 	//   flag: g=true
 	//     flag: f=true
+	//     flag: l=false
+	// [--l inner]
+	//   cmd: outer.inner
+	//   flag: g=false
+	//     flag: f=false
+	//     flag: l=true
+	// [inner --l] err: inner: unexpected flag: --l
+	//   cmd: outer.inner
+	//   flag: g=false
+	//     flag: f=false
+	//     flag: l=false
+}
+
+func ExampleFlag_Persistent() {
+	app := run.MustApp("outer", "Has global flags.",
+		run.Enabler("verbose", "Log more", false, true).Flags('v', "verbose").Persistent(),
+		run.Enabler("local", "Only for outer", false, true).Flag(),
+		run.MustCmd("inner", "Has local flags.",
+			run.String("name", "Name to use").Flag(),
+		),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"outer", "inner", "--help"}))
+
+	// output:
+	// Usage: outer inner [flags]
+	//
+	// Has local flags.
+	//
+	// Flags:
+	//   -h, --help       Show context-sensitive help.
+	//       --name       Name to use
+	//
+	// Global Flags:
+	//   -v, --verbose    Log more
 }
 
 func ExampleString() {
@@ -372,7 +419,58 @@ func ExampleNamedOf() {
 	//   arg: digit=2
 	// [four] err: d: "four" not one of "one", "two", "three"
 	//   cmd: named
-	//   arg: digit=2
+	//   arg: digit=0
+}
+
+func ExampleNamedOf_help() {
+	app := run.MustApp("show", "Shows things.",
+		run.StringOf("format", "Output format", "text", "json", "yaml").Flag(),
+		run.NamedOf("level", "Detail level", []run.NamedValue[int]{
+			{Name: "low", Desc: "Names only", Value: 1},
+			{Name: "high", Desc: "Everything", Value: 2},
+		}).Flag(),
+		run.StringOf("month", "Month to show", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug").Arg("month"),
+	)
+	env := run.DefaultEnviron().WithStderr(os.Stdout)
+	app.Main(context.Background(), env.WithArgs([]string{"show", "--help"}))
+	app.Main(context.Background(), env.WithArgs([]string{"show", "--level=mid", "jan"}))
+	app.Main(context.Background(), env.WithArgs([]string{"show", "dec"}))
+
+	// output:
+	// Usage: show [flags] <month>
+	//
+	// Shows things.
+	//
+	// Arguments:
+	//   <month>    Month to show
+	//                jan
+	//                feb
+	//                mar
+	//                apr
+	//                may
+	//                jun
+	//                jul
+	//                aug
+	//
+	// Flags:
+	//   -h, --help                Show context-sensitive help.
+	//       --format=text|json|yaml
+	//                             Output format
+	//       --level               Detail level
+	//                               low    Names only
+	//                               high   Everything
+	// show: error: --level=mid: "mid" not one of "low", "high"
+	// Run "show --help" for more information.
+	// show: error: month: "dec" not one of:
+	//   jan
+	//   feb
+	//   mar
+	//   apr
+	//   may
+	//   jun
+	//   jul
+	//   aug
+	// Run "show --help" for more information.
 }
 
 func ExampleNamedSliceOf() {
@@ -441,6 +539,974 @@ func ExampleFlag_Default() {
 	//   flag: digit=0
 }
 
+func ExampleFlag_DefaultFunc() {
+	try := func(args ...string) {
+		app := run.MustApp("cache", "Caches things.",
+			run.String("cache-dir", "Where to cache").Flag().
+				DefaultFunc(func(ctx run.Context) (string, error) {
+					return ctx.Getenv("XDG_CACHE_HOME") + "/cache", nil
+				}).
+				DefaultText("auto"),
+		)
+		env := run.DefaultEnviron().WithVariables(run.Variables{"XDG_CACHE_HOME": "/home/me/.cache"})
+		app.DebugEnv(env, args...)
+	}
+	try()
+	try("--cache-dir", "/tmp")
+
+	// output:
+	// []
+	//   cmd: cache
+	//   flag: cache-dir=/home/me/.cache/cache
+	// [--cache-dir /tmp]
+	//   cmd: cache
+	//   flag: cache-dir=/tmp
+}
+
+func ExampleFlag_DefaultText() {
+	app := run.MustApp("cache", "Caches things.",
+		run.String("cache-dir", "Where to cache").Flag().
+			DefaultFunc(func(ctx run.Context) (string, error) { return "/tmp", nil }).
+			DefaultText("auto"),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"cache", "--help"}))
+
+	// output:
+	// Usage: cache [flags]
+	//
+	// Caches things.
+	//
+	// Flags:
+	//   -h, --help              Show context-sensitive help.
+	//       --cache-dir=auto    Where to cache
+}
+
+func ExampleContext_DumpConfig() {
+	try := func(args ...string) {
+		timeout := run.String("timeout", "")
+		app := run.MustApp("prov", "",
+			timeout.Flag().Env("PROV_TIMEOUT").Default("30s"),
+			run.String("region", "").Flag().Env("PROV_REGION"),
+			run.String("user", "").Flag(),
+			run.String("target", "").Arg("target"),
+			run.Handler(func(ctx run.Context) error {
+				fmt.Println(args, "timeout from", ctx.Source(timeout))
+				ctx.DumpConfig(ctx.Stdout)
+				return nil
+			}),
+		)
+		env := run.DefaultEnviron().
+			WithArgs(append([]string{"prov"}, args...)).
+			WithVariables(run.Variables{"PROV_REGION": "west"})
+		app.Main(context.Background(), env)
+	}
+	try("host")
+	try("--timeout", "5s", "--user", "me", "host")
+
+	// output:
+	// [host] timeout from default
+	//   cmd: prov
+	//   flag: timeout=30s (default)
+	//   flag: region=west (env $PROV_REGION)
+	//   flag: user= (unset)
+	//   arg: target=host (command line)
+	// [--timeout 5s --user me host] timeout from command line
+	//   cmd: prov
+	//   flag: timeout=5s (command line)
+	//   flag: region=west (env $PROV_REGION)
+	//   flag: user=me (command line)
+	//   arg: target=host (command line)
+}
+
+func ExampleContext_SetFrom() {
+	config := map[string]string{"user": "ops"} // as read from prov.toml
+	user := run.String("user", "")
+	app := run.MustApp("prov", "",
+		user.Flag(),
+		run.Handler(func(ctx run.Context) error {
+			if !ctx.IsSet(user) {
+				if err := ctx.SetFrom(user, config["user"], run.ConfigSource("prov.toml", "user")); err != nil {
+					return err
+				}
+			}
+			fmt.Println(user.Value(), "from", ctx.Source(user))
+			return nil
+		}),
+	)
+
+	// sources reflect only the most recent parse
+	env := run.DefaultEnviron()
+	app.Main(context.Background(), env.WithArgs([]string{"prov", "--user", "me"}))
+	app.Main(context.Background(), env.WithArgs([]string{"prov"}))
+
+	// output:
+	// me from command line
+	// ops from config prov.toml [user]
+}
+
+func ExampleContext_IsSet() {
+	try := func(args ...string) {
+		name := run.String("name", "")
+		size := run.Int("size", "", 10)
+		app := run.MustApp("merge", "",
+			name.Flag().Default("anon"),
+			size.Flag(),
+			run.Handler2(func(ctx run.Context, n string, size run.Presence[int]) error {
+				fmt.Println(args, "name:", n, ctx.IsSet(name))
+				fmt.Println(args, "size:", size.Value, size.Set)
+				return nil
+			}, name, size.Presence()),
+		)
+		app.Main(context.Background(), run.DefaultEnviron().WithArgs(append([]string{"merge"}, args...)))
+	}
+	try()
+	try("--name", "bob", "--size", "0")
+
+	// parsing again with the same app reports only the new arguments
+	name := run.String("name", "")
+	app := run.MustApp("merge", "",
+		name.Flag(),
+		run.Handler(func(ctx run.Context) error {
+			fmt.Println(ctx.Args[1:], "name:", name.Value(), ctx.IsSet(name))
+			return nil
+		}),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"merge", "--name", "x"}))
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"merge"}))
+
+	// output:
+	// [] name: anon false
+	// [] size: 0 false
+	// [--name bob --size 0] name: bob true
+	// [--name bob --size 0] size: 0 true
+	// [--name x] name: x true
+	// [] name:  false
+}
+
+func ExampleUse() {
+	trace := func(name string) run.Middleware {
+		return func(next run.Handler) run.Handler {
+			return func(ctx run.Context) error {
+				fmt.Println("enter", name)
+				defer fmt.Println("leave", name)
+				return next(ctx)
+			}
+		}
+	}
+	app := run.MustApp("mw", "",
+		run.Use(trace("root"), trace("audit")),
+		run.MustCmd("sub", "",
+			run.Use(trace("sub")),
+			run.Handler(func(ctx run.Context) error {
+				fmt.Println("run", ctx.Command.Name())
+				return nil
+			}),
+		),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"mw", "sub"}))
+
+	// output:
+	// enter root
+	// enter audit
+	// enter sub
+	// run mw.sub
+	// leave sub
+	// leave audit
+	// leave root
+}
+
+func ExampleBefore() {
+	app := run.MustApp("hooks", "",
+		run.Before(func(ctx run.Context) error {
+			fmt.Println("before root")
+			ctx.Defer(func() error { fmt.Println("cleanup root"); return nil })
+			return nil
+		}),
+		run.After(func(ctx run.Context, err error) error {
+			fmt.Println("after root:", err)
+			return err
+		}),
+		run.MustCmd("sub", "",
+			run.Before(func(ctx run.Context) error {
+				fmt.Println("before sub")
+				ctx.Defer(func() error { return errors.New("flush failed") })
+				return nil
+			}),
+			run.After(func(ctx run.Context, err error) error {
+				fmt.Println("after sub:", err)
+				return fmt.Errorf("sub: %w", err)
+			}),
+			run.Handler(func(ctx run.Context) error {
+				fmt.Println("run sub")
+				return errors.New("oops")
+			}),
+		),
+	)
+	err := app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"hooks", "sub"}))
+	fmt.Println(err)
+
+	// output:
+	// before root
+	// before sub
+	// run sub
+	// after sub: oops
+	// after root: sub: oops
+	// cleanup root
+	// sub: oops
+	// flush failed
+}
+
+func ExampleExitCode() {
+	app := run.MustApp("exits", "",
+		run.MustCmd("fail", "", run.Handler(func(run.Context) error { return errors.New("failed") })),
+		run.MustCmd("ok", "", run.Handler(func(run.Context) error { return nil })),
+	)
+	try := func(args ...string) {
+		env := run.DefaultEnviron().WithArgs(append([]string{"exits"}, args...)).WithStderr(os.Stdout)
+		err := app.Main(context.Background(), env)
+		fmt.Println(args, "exit", run.ExitCode(err))
+	}
+	try("ok")
+	try("fail")
+	try("ok", "extra")
+	try("bogus")
+	app.SetExitCodes(run.SysExits)
+	try("bogus")
+
+	// output:
+	// [ok] exit 0
+	// exits: error: failed
+	// [fail] exit 1
+	// exits: error: ok: unexpected argument: "extra"
+	// Run "exits ok --help" for more information.
+	// [ok extra] exit 2
+	// exits: error: unexpected argument: "bogus"
+	// Run "exits --help" for more information.
+	// [bogus] exit 2
+	// exits: error: unexpected argument: "bogus"
+	// Run "exits --help" for more information.
+	// [bogus] exit 64
+}
+
+func ExampleApplication_HandleSignals() {
+	sigs := make(chan os.Signal, 2)
+	exited := make(chan struct{})
+	app := run.MustApp("sigs", "",
+		run.MustCmd("graceful", "", run.Handler(func(ctx run.Context) error {
+			sigs <- os.Interrupt
+			<-ctx.Done()
+			fmt.Println("stopping:", context.Cause(ctx))
+			return ctx.Err()
+		})),
+		run.MustCmd("stuck", "", run.Handler(func(ctx run.Context) error {
+			sigs <- os.Interrupt
+			<-ctx.Done()
+			sigs <- os.Interrupt
+			<-exited
+			return nil
+		})),
+	)
+	app.HandleSignals(0)
+	try := func(args ...string) {
+		env := run.DefaultEnviron().
+			WithArgs(append([]string{"sigs"}, args...)).
+			WithStderr(os.Stdout).
+			WithSignals(sigs).
+			WithExit(func(code int) {
+				fmt.Println("forced exit", code)
+				close(exited)
+			})
+		err := app.Main(context.Background(), env)
+		fmt.Println(args, "exit", run.ExitCode(err))
+	}
+	try("graceful")
+	try("stuck")
+
+	// output:
+	// stopping: interrupted: interrupt
+	// sigs: error: interrupted: interrupt
+	// [graceful] exit 130
+	// forced exit 137
+	// [stuck] exit 0
+}
+
+func ExampleParseError() {
+	app := run.MustApp("parse", "",
+		run.Int("count", "", 10).Flag(),
+		run.String("name", "").Arg("name"),
+		run.String("greeting", "").Arg("greeting").Default("hi"),
+	)
+	try := func(args ...string) {
+		_, err := app.Parse(run.DefaultEnviron().WithArgs(append([]string{"parse"}, args...)))
+		var pe *run.ParseError
+		if errors.As(err, &pe) {
+			fmt.Printf("%v: kind=%q token=%q value=%q index=%d cause=%v\n", args, pe.Kind, pe.Token, pe.Value, pe.Index, pe.Err)
+			if pe.Extra != nil {
+				fmt.Println("  extra:", pe.Extra)
+			}
+			for _, arg := range pe.Missing {
+				fmt.Println("  missing:", arg.Name())
+			}
+		}
+	}
+	try("--count", "many", "bob")
+	try("--count=many", "bob")
+	try("--size", "bob")
+	try("bob", "hello", "alice", "carol")
+	try()
+
+	// output:
+	// [--count many bob]: kind="flag value" token="--count" value="many" index=1 cause=parsing "many" as int: invalid syntax
+	// [--count=many bob]: kind="flag value" token="--count=many" value="many" index=1 cause=parsing "many" as int: invalid syntax
+	// [--size bob]: kind="extra flag" token="--size" value="" index=1 cause=<nil>
+	// [bob hello alice carol]: kind="extra args" token="alice" value="" index=3 cause=<nil>
+	//   extra: [alice carol]
+	// []: kind="missing args" token="" value="" index=-1 cause=<nil>
+	//   missing: name
+}
+
+type quotaError struct{ limit int }
+
+func (e quotaError) Error() string { return "over quota" }
+func (e quotaError) ErrorFields() map[string]any {
+	return map[string]any{"kind": "quota", "limit": e.limit}
+}
+
+func ExampleJSONErrors() {
+	app := run.MustApp("tool", "",
+		run.StringOf("format", "", "text", "json").Flag(),
+		run.Enabler("verbose", "", false, true).Flag(),
+		run.MustCmd("upload", "", run.Handler(func(run.Context) error { return quotaError{10} })),
+		run.MustCmd("download", "", run.Handler(func(run.Context) error { return nil })),
+	)
+	app.SetErrorFormatter(run.JSONErrors)
+	try := func(args ...string) {
+		env := run.DefaultEnviron().WithArgs(append([]string{"tool"}, args...)).WithStderr(os.Stdout)
+		app.Main(context.Background(), env)
+	}
+	try("--verbos")
+	try("--format", "yaml")
+	try("--format", "jsn")
+	try("uplaod")
+	try()
+	try("upload")
+
+	// output:
+	// {"command":"tool","kind":"extra flag","message":"unexpected flag: --verbos","suggestions":["--verbose"],"token":"--verbos"}
+	// {"command":"tool","flag":"--format","kind":"flag value","message":"--format: \"yaml\" not one of \"text\", \"json\"","token":"--format","value":"yaml"}
+	// {"command":"tool","flag":"--format","kind":"flag value","message":"--format: \"jsn\" not one of \"text\", \"json\"","suggestions":["json"],"token":"--format","value":"jsn"}
+	// {"command":"tool","kind":"extra args","message":"unexpected argument: \"uplaod\"","suggestions":["upload"],"token":"uplaod"}
+	// {"command":"tool","kind":"missing command","message":"expected <command>","suggestions":["upload","download"]}
+	// {"command":"tool upload","kind":"quota","limit":10,"message":"over quota"}
+}
+
+func ExampleApplication_WriteMan() {
+	remote := run.MustCmd("remote", "Manage remotes",
+		run.Details("Remotes are named repositories.\n\n# Examples\n\n\tapp remote add origin https://example.com/\n"),
+		run.MustCmd("add", "Add a remote",
+			run.String("name", "Remote name").Arg("name"),
+			run.String("url", "Remote URL").Arg("url"),
+		),
+	)
+	app := run.MustApp("app", "Does things.",
+		run.Enabler("verbose", "Log more", false, true).Flags('v', "verbose").Persistent(),
+		run.String("config", "Config file").Flags(0, "config", "<file>").Env("APP_CONFIG"),
+	)
+	app.SetCommands(remote, run.ManCommand(app))
+	app.WriteMan(os.Stdout, remote)
+
+	// output:
+	// .TH "APP\-REMOTE" 1 "" "app" "app Manual"
+	// .SH NAME
+	// app\-remote \- Manage remotes
+	// .SH SYNOPSIS
+	// .B app remote
+	// <command>
+	// .SH DESCRIPTION
+	// Manage remotes
+	// .PP
+	// Remotes are named repositories.
+	// .SS "Examples"
+	// .PP
+	// .RS 4
+	// .nf
+	// app remote add origin https://example.com/
+	// .fi
+	// .RE
+	// .SH OPTIONS
+	// .TP
+	// \fB\-h\fR, \fB\-\-help\fR
+	// Show context\-sensitive help.
+	// .TP
+	// \fB\-v\fR, \fB\-\-verbose\fR
+	// Log more
+	// .SH COMMANDS
+	// .TP
+	// \fBadd\fR
+	// Add a remote
+	// .SH SEE ALSO
+	// \fBapp\fR(1), \fBapp\-remote\-add\fR(1)
+}
+
+func ExampleApplication_WriteMarkdown() {
+	name := run.String("name", "Remote name")
+	add := run.MustCmd("add", "Add a remote",
+		name.Arg("name"),
+		run.String("url", "Remote URL").Arg("url"),
+	)
+	app := run.MustApp("app", "Does things.",
+		run.DetailsFor("Remote names must be *unique*.", name),
+		run.Enabler("verbose", "Log more", false, true).Flags('v', "verbose").Persistent(),
+		run.MustCmd("remote", "Manage remotes", add),
+	)
+	app.WriteMarkdown(os.Stdout, add)
+
+	// output:
+	// # app remote add
+	//
+	// Add a remote
+	//
+	// ```
+	// app remote add <name> <url>
+	// ```
+	//
+	// ## Arguments
+	//
+	// - `<name>`: Remote name
+	// - `<url>`: Remote URL
+	//
+	// ## Flags
+	//
+	// - `-h, --help`: Show context-sensitive help.
+	//
+	// ## Global Flags
+	//
+	// - `-v, --verbose`: Log more
+	//
+	// ## See Also
+	//
+	// - [app remote](app-remote.md)
+	// - [app](app.md)
+}
+
+func ExampleTemplateHelp() {
+	tmpl := template.Must(template.New("help").Funcs(run.HelpFuncs()).Parse(
+		`{{join .Usage " "}} - {{.Description}}
+{{text .Width .Details}}{{range .Sections}}[{{.Title}}]
+{{range .Entries}}  {{.Name}}: {{.Description}}
+{{end}}{{end}}`))
+
+	app := run.MustApp("tmpl", "Uses a template.",
+		run.Details("Details are wrapped to the terminal width, as in the default help."),
+		run.String("name", "Name to use").Flag(),
+		run.String("file", "File to read").Arg("file"),
+	)
+	app.SetHelpRenderer(run.TemplateHelp(tmpl))
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"tmpl", "--help"}).WithTerminalWidth(40))
+
+	// output:
+	// tmpl [flags] <file> - Uses a template.
+	// Details are wrapped to the terminal
+	// width, as in the default help.
+	// [Arguments]
+	//   <file>: File to read
+	// [Flags]
+	//   -h, --help: Show context-sensitive help.
+	//   --name: Name to use
+}
+
+func ExampleEnviron_WithTerminalWidth() {
+	app := run.MustApp("wrap", "Wraps help.",
+		run.Details("Details are wrapped to the same width as the rest of help, rather than a fixed width."),
+		run.String("größe", "Sets the size used when rendering output, in characters").Flag(),
+		run.String("名前", "The name to greet").Flag(),
+	)
+	env := run.DefaultEnviron().WithArgs([]string{"wrap", "--help"}).WithTerminalWidth(50)
+	app.Main(context.Background(), env)
+
+	// output:
+	// Usage: wrap [flags]
+	//
+	// Wraps help.
+	//
+	// Details are wrapped to the same width as the rest
+	// of help, rather than a fixed width.
+	//
+	// Flags:
+	//   -h, --help     Show context-sensitive help.
+	//       --größe    Sets the size used when rendering
+	//                  output, in characters
+	//       --名前     The name to greet
+}
+
+func ExampleApplication_SetColor() {
+	app := run.MustApp("paint", "Paints output.",
+		run.String("level", "Sets the level").Flags('l', "level", "<n>"),
+	)
+	app.SetColor(run.ColorAuto)
+	app.SetTheme(run.Theme{Heading: "1", Flag: "36", Placeholder: "33", Error: "31"})
+
+	// show escape sequences so they are visible
+	var out strings.Builder
+	show := func(env run.Environ) {
+		out.Reset()
+		app.Main(context.Background(), env.WithOutput(&out))
+		fmt.Print(strings.ReplaceAll(out.String(), "\x1b", "^["))
+	}
+	env := run.DefaultEnviron().WithTerminal(true)
+	show(env.WithArgs([]string{"paint", "--help"}))
+	show(env.WithArgs([]string{"paint", "--color=never", "--levle"}))
+	show(env.WithArgs([]string{"paint", "--levle"}))
+	show(env.WithArgs([]string{"paint", "--levle"}).WithVariables(run.Variables{"NO_COLOR": "1"}))
+
+	// output:
+	// ^[[1mUsage:^[[0m paint [flags]
+	//
+	// Paints output.
+	//
+	// ^[[1mFlags:^[[0m
+	//   ^[[36m-h^[[0m, ^[[36m--help^[[0m                Show context-sensitive help.
+	//   ^[[36m-l^[[0m, ^[[36m--level^[[0m=^[[33m<n>^[[0m           Sets the level
+	//       ^[[36m--color^[[0m=^[[33mauto|always|never^[[0m
+	//                             When to use color (default: auto)
+	// paint: error: unexpected flag: --levle
+	// Run "paint --help" for more information.
+	// paint: ^[[31merror:^[[0m unexpected flag: --levle
+	// Run "paint --help" for more information.
+	// paint: error: unexpected flag: --levle
+	// Run "paint --help" for more information.
+}
+
+func ExampleApplication_SetDefaultsStyle() {
+	app := run.MustApp("fetch", "Fetches a page.",
+		run.String("timeout", "How long to wait").Flags('t', "timeout", "<dur>").Default("30s").Env("APP_TIMEOUT"),
+		run.String("user", "Who to fetch as").Flags('u', "user", "<name>").Required(),
+		run.String("url", "Page to fetch").Arg("url").Default("/"),
+		run.Handler(func(run.Context) error { return nil }),
+	)
+	env := run.DefaultEnviron().WithStderr(os.Stdout).WithVariables(run.Variables{})
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "--help"}))
+	app.SetDefaultsStyle(run.DefaultsTrailer)
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "--help"}))
+	app.Main(context.Background(), env.WithArgs([]string{"fetch"}))
+
+	// output:
+	// Usage: fetch [flags] [<url>]
+	//
+	// Fetches a page.
+	//
+	// Arguments:
+	//   [<url>]    Page to fetch (default: /)
+	//
+	// Flags:
+	//   -h, --help           Show context-sensitive help.
+	//   -t, --timeout=30s    How long to wait (env: $APP_TIMEOUT)
+	//   -u, --user=<name>    Who to fetch as (required)
+	// Usage: fetch [flags] [<url>]
+	//
+	// Fetches a page.
+	//
+	// Arguments:
+	//   [<url>]    Page to fetch (default: /)
+	//
+	// Flags:
+	//   -h, --help             Show context-sensitive help.
+	//   -t, --timeout=<dur>    How long to wait (default: 30s, env: $APP_TIMEOUT)
+	//   -u, --user=<name>      Who to fetch as (required)
+	// fetch: error: missing required flag: --user
+	// Run "fetch --help" for more information.
+}
+
+func ExampleHelpTopic() {
+	app := run.MustApp("git", "Tracks changes.",
+		run.HelpTopic("environment", "Variables that affect git.\n\nGIT_DIR sets the repository path."),
+		run.MustCmd("remote", "Manages remotes",
+			run.MustCmd("add", "Adds a remote", run.String("url", "Remote URL").Arg("url"),
+				run.Handler(func(run.Context) error { return nil })),
+		),
+		run.MustCmd("secret", "Does secret things", run.Unlisted(), run.NoHelp(),
+			run.Handler(func(run.Context) error { return nil })),
+	)
+	env := run.DefaultEnviron().WithStderr(os.Stdout)
+	app.Main(context.Background(), env.WithArgs([]string{"git", "help"}))
+	fmt.Println("---")
+	app.Main(context.Background(), env.WithArgs([]string{"git", "help", "remote", "add"}))
+	fmt.Println("---")
+	app.Main(context.Background(), env.WithArgs([]string{"git", "help", "environment"}))
+	fmt.Println("---")
+	app.Main(context.Background(), env.WithArgs([]string{"git", "help", "secret"}))
+	app.Main(context.Background(), env.WithArgs([]string{"git", "help", "remote", "rm"}))
+
+	// output:
+	// Usage: git <command> [flags]
+	//
+	// Tracks changes.
+	//
+	// Flags:
+	//   -h, --help    Show context-sensitive help.
+	//
+	// Commands:
+	//   remote         Manages remotes
+	//
+	// Topics:
+	//   environment    Variables that affect git.
+	//
+	// Run "git <command> --help" or "git help <command>" for more information on a command.
+	// Run "git help <topic>" for more information on a topic.
+	// ---
+	// Usage: git remote add <url>
+	//
+	// Adds a remote
+	//
+	// Arguments:
+	//   <url>     Remote URL
+	//
+	// Flags:
+	//   -h, --help    Show context-sensitive help.
+	// ---
+	// Variables that affect git.
+	//
+	// GIT_DIR sets the repository path.
+	// ---
+	// git: error: secret: help requested
+	// git: error: remote: unexpected argument: "rm"
+	// Run "git remote --help" for more information.
+}
+
+func ExampleApplication_ValidateExamples() {
+	app := run.MustApp("greet", "Greets people.",
+		run.String("greeting", "How to greet").Flags('g', "greeting", "<word>"),
+		run.StringSlice("names", "Who to greet").Args("name"),
+		run.Example("greet -g Hello 'Ada Lovelace'", "Greets Ada politely."),
+		run.Example("greet --salutation Hi Bob", "Greets Bob casually."),
+		run.Handler(func(run.Context) error { return nil }),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"greet", "--help"}))
+
+	// in a test, fail if any example no longer parses
+	if err := app.ValidateExamples(); err != nil {
+		fmt.Println(err)
+	}
+
+	// output:
+	// Usage: greet [flags] <name> ...
+	//
+	// Greets people.
+	//
+	// Arguments:
+	//   <name> ...    Who to greet
+	//
+	// Flags:
+	//   -h, --help               Show context-sensitive help.
+	//   -g, --greeting=<word>    How to greet
+	//
+	// Examples:
+	//   greet -g Hello 'Ada Lovelace'
+	//       Greets Ada politely.
+	//
+	//   greet --salutation Hi Bob
+	//       Greets Bob casually.
+	// example "greet --salutation Hi Bob": unexpected flag: --salutation
+}
+
+func ExampleApplication_ValidateExamples_independent() {
+	app := run.MustApp("fetch", "Fetches things.",
+		run.Enabler("verbose", "Say more", false, true).Flags('v', "verbose"),
+		run.String("user", "Who to fetch as").Flag().Required(),
+		run.String("item", "What to fetch").Arg("item"),
+		run.Example("fetch -v --user a x", "Fetches x as a."),
+		run.Example("fetch -v --user b y", "Fetches y as b."),
+		run.Example("fetch -v z", "Forgets who to fetch as."),
+		run.Example("fetch --user c", "Forgets what to fetch."),
+		run.Handler(func(run.Context) error { return nil }),
+	)
+	app.SetPrompting(true) // examples are never prompted for
+
+	// each example is parsed on its own
+	fmt.Println(app.ValidateExamples())
+
+	// output:
+	// example "fetch -v z": missing required flag: --user
+	// example "fetch --user c": expected "<item>"
+}
+
+func ExampleApplication_ValidateExamples_help() {
+	app := run.MustApp("git", "Tracks changes.",
+		run.MustCmd("remote", "Manages remotes",
+			run.Example("git remote --help", "Shows help for remote."),
+			run.Example("git help remote", "Also shows help for remote."),
+			run.Example("git --help", "Shows help for git instead."),
+			run.Handler(func(run.Context) error { return nil }),
+		),
+	)
+
+	fmt.Println(app.ValidateExamples())
+
+	// output:
+	// example "git --help": runs "git" instead of "git remote"
+}
+
+func ExampleVersion() {
+	// use run.BuildVersion() to describe the running binary
+	info := run.VersionInfo{Version: "v1.2.3", Revision: "0123456789abcdef", Dirty: true, CommitTime: "2024-05-01T12:00:00Z", GoVersion: "go1.22.0"}
+	app := run.MustApp("tool", "Does things.", run.Version(info))
+	_, err := run.Cmd("sub", "", run.Version(info))
+	fmt.Println(err)
+	app.SetCommands(
+		run.VersionCommand(app, info),
+		run.MustCmd("do", "Does a thing", run.Handler(func(run.Context) error { return nil })),
+	)
+
+	env := run.DefaultEnviron()
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "--help"}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "-V"}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "do", "--version", "--json"}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "version"}))
+
+	// output:
+	// sub: Version applies only to an application
+	// Usage: tool <command> [flags]
+	//
+	// Does things.
+	//
+	// Flags:
+	//   -h, --help       Show context-sensitive help.
+	//   -V, --version    Show version information.
+	//
+	// Commands:
+	//   version    Show version information
+	//   do         Does a thing
+	//
+	// Run "tool <command> --help" or "tool help <command>" for more information on a command.
+	// tool v1.2.3 (0123456789ab, dirty, committed 2024-05-01T12:00:00Z, go1.22.0)
+	// {"version":"v1.2.3","revision":"0123456789abcdef","dirty":true,"commitTime":"2024-05-01T12:00:00Z","goVersion":"go1.22.0"}
+	// tool v1.2.3 (0123456789ab, dirty, committed 2024-05-01T12:00:00Z, go1.22.0)
+}
+
+func ExampleCompletionCommand() {
+	app := run.MustApp("tool", "Does things.")
+	app.SetCommands(
+		run.CompletionCommand(app),
+		run.MustCmd("show", "Shows things",
+			run.StringOf("format", "Output format", "text", "json").Flags('f', "format", "<fmt>"),
+			run.String("debug-token", "Debugging aid").Flag().Hidden(),
+			run.Handler(func(run.Context) error { return nil }),
+		),
+		run.MustCmd("gen-man", "Generates man pages", run.Unlisted()),
+	)
+
+	// shells run these to complete "tool ", "tool show --", and "tool show --format "
+	env := run.DefaultEnviron()
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "__complete", ""}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "__complete", "show", "--"}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "__complete", "show", "--format", "j"}))
+	app.Main(context.Background(), env.WithArgs([]string{"tool", "__complete", "show", "--format="}))
+
+	// output:
+	// completion	Generate a shell completion script
+	// show	Shows things
+	// help	Show help for a command or topic
+	// :0
+	// --help	Show context-sensitive help.
+	// --format	Output format
+	// :0
+	// json
+	// :0
+	// --format=text
+	// --format=json
+	// :0
+}
+
+func ExampleCompleter() {
+	remote := run.StringOf("remote", "Remote to fetch from", "origin", "upstream")
+	branches := map[string][]string{"origin": {"main", "fix-docs"}, "upstream": {"main", "next"}}
+	branch := run.String("branch", "Branch to fetch").Complete(func(ctx run.Context, prefix string) []run.Candidate {
+		var cands []run.Candidate
+		for _, b := range branches[remote.Value()] {
+			cands = append(cands, run.Candidate{Value: b})
+		}
+		return cands
+	})
+	app := run.MustApp("fetch", "Fetches things.",
+		remote.Flags('r', "remote", "<name>").Default("origin"),
+		run.File("log", "Log file").Flag(),
+		branch.Arg("branch"),
+		run.Handler(func(run.Context) error { return nil }),
+	)
+	app.SetCommands(run.CompletionCommand(app))
+
+	// the line so far is parsed, so completers can use earlier values
+	env := run.DefaultEnviron()
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "__complete", "-r", "upstream", "n"}))
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "__complete", "--remote=origin", ""}))
+	// and see the defaults of options not given
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "__complete", "f"}))
+	// file options ask the shell to complete file names
+	app.Main(context.Background(), env.WithArgs([]string{"fetch", "__complete", "--log", ""}))
+
+	// output:
+	// next
+	// :0
+	// main
+	// fix-docs
+	// :0
+	// fix-docs
+	// :0
+	// :2
+}
+
+func ExampleApplication_SetPrompting() {
+	target := run.NamedOf("target", "Where to deploy", []run.NamedValue[string]{
+		{Name: "staging", Value: "staging.example.com"},
+		{Name: "prod", Value: "www.example.com", Desc: "The live site"},
+	})
+	replicas := run.Int("replicas", "How many to run", 10)
+	app := run.MustApp("deploy", "Deploys things.",
+		replicas.Flags('n', "replicas", "<n>").Required(),
+		target.Arg("target"),
+		run.String("region", "").Arg("region").Default("eu"),
+		run.Handler(func(ctx run.Context) error {
+			fmt.Println("deploying", replicas.Value(), "to", target.Value(), "from", ctx.Source(target))
+			return nil
+		}),
+	)
+	app.SetPrompting(true)
+
+	// answers are typed at a terminal; invalid ones are asked for again
+	env := run.DefaultEnviron().WithArgs([]string{"deploy"}).WithStderr(os.Stdout).WithTerminal(true)
+	app.Main(context.Background(), env.WithInput(strings.NewReader("qa\n2\nthree\n3\n")))
+	fmt.Println()
+
+	// without a terminal, or once input runs out, missing options are errors
+	app.Main(context.Background(), env.WithTerminal(false))
+	app.Main(context.Background(), env.WithInput(strings.NewReader("prod\n")))
+	app.Main(context.Background(), env.WithInput(iotest.ErrReader(errors.New("terminal closed"))))
+
+	// output:
+	// <target> (Where to deploy):
+	//   1) staging
+	//   2) prod      The live site
+	// <target> [1-2]: deploy: error: target: "qa" not one of "staging", "prod"
+	// <target> [1-2]: --replicas (How many to run): deploy: error: --replicas: parsing "three" as int: invalid syntax
+	// --replicas (How many to run): deploying 3 to www.example.com from prompt
+	//
+	// deploy: error: expected "<target>"
+	// Run "deploy --help" for more information.
+	// <target> (Where to deploy):
+	//   1) staging
+	//   2) prod      The live site
+	// <target> [1-2]: --replicas (How many to run): deploy: error: missing required flag: --replicas
+	// Run "deploy --help" for more information.
+	// <target> (Where to deploy):
+	//   1) staging
+	//   2) prod      The live site
+	// <target> [1-2]: deploy: error: expected "<target>": terminal closed
+	// Run "deploy --help" for more information.
+}
+
+func ExampleSecret() {
+	token := run.Secret("token", "API token")
+	pin := run.Int("pin", "Unlock code", 10).Sensitive()
+	app := run.MustApp("login", "Logs in.",
+		token.Flag().Env("LOGIN_TOKEN").Default("s3cr3t"),
+		token.FileFlag("token-file"),
+		pin.Flag(),
+		run.Handler(func(ctx run.Context) error {
+			ctx.DumpConfig(ctx.Stdout)
+			fmt.Println(len(token.Value()), "characters")
+			return nil
+		}),
+	)
+
+	env := run.DefaultEnviron().WithStderr(os.Stdout).WithVariables(run.Variables{}).WithTerminalWidth(80)
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--help"}))
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--pin=12ab"}))
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token-file", "-"}).WithInput(strings.NewReader("hunter2\n")))
+
+	// output:
+	// Usage: login [flags]
+	//
+	// Logs in.
+	//
+	// Flags:
+	//   -h, --help                Show context-sensitive help.
+	//       --token=***           API token (env: $LOGIN_TOKEN)
+	//       --token-file=<file>
+	//                             API token from a file, or - for stdin
+	//       --pin                 Unlock code
+	// login: error: --pin=***: parsing "***" as int: invalid syntax
+	// Run "login --help" for more information.
+	//   cmd: login
+	//   flag: token=*** (stdin)
+	//   flag: token-file=- (command line)
+	//   flag: pin=*** (unset)
+	// 7 characters
+}
+
+func ExampleSecret_debug() {
+	app := run.MustApp("login", "Logs in.",
+		run.Secret("token", "API token").Flag(),
+		run.String("user", "Who to log in as").Arg("user"),
+		run.Secret("password", "Their password").Arg("password"),
+		run.Handler(func(run.Context) error { return nil }),
+	)
+	app.Debug("--token", "s3cr3t", "ada", "hunter2")
+	app.Debug("--token=s3cr3t", "ada", "hunter2", "extra")
+
+	// output:
+	// [--token *** ada ***]
+	//   cmd: login
+	//   flag: token=***
+	//   arg: user=ada
+	//   arg: password=***
+	// [--token=*** ada *** extra] err: unexpected argument: "extra"
+	//   cmd: login
+	//   flag: token=***
+	//   arg: user=ada
+	//   arg: password=***
+}
+
+func ExampleSecret_file() {
+	f, _ := os.CreateTemp("", "token")
+	defer os.Remove(f.Name())
+	f.WriteString("from-file\n")
+	f.Close()
+
+	token := run.Secret("token", "API token")
+	code := run.Int("code", "Unlock code", 10).Sensitive()
+	app := run.MustApp("login", "Logs in.",
+		token.Flag().Required(),
+		token.FileFlag("token-file"),
+		code.Flag(),
+		run.Handler(func(ctx run.Context) error {
+			fmt.Println(token.Value(), "from", strings.Replace(ctx.Source(token).String(), f.Name(), "<file>", 1))
+			return nil
+		}),
+	)
+
+	env := run.DefaultEnviron().WithStderr(os.Stdout)
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token-file", f.Name()}))
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token", "from-flag"}))
+	// Parse, as used by ValidateExamples, leaves the file for Main to read
+	_, err := app.Parse(env.WithArgs([]string{"login", "--token-file", "missing.txt"}))
+	fmt.Println("parse:", err)
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token-file", "missing.txt"}))
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token", "x", "--code", "1o"}))
+	// the cause is withheld if it would show the value elsewhere
+	app.Main(context.Background(), env.WithArgs([]string{"login", "--token", "x", "--code", "a"}))
+
+	// output:
+	// from-file from file <file>
+	// from-flag from command line
+	// parse: <nil>
+	// login: error: --token-file: open missing.txt: no such file or directory
+	// Run "login --help" for more information.
+	// login: error: --code: parsing "***" as int: invalid syntax
+	// Run "login --help" for more information.
+	// login: error: --code: invalid value
+	// Run "login --help" for more information.
+}
+
 func ExampleApplication_Ferror() {
 	app := run.MustApp("commands", "",
 		run.MustCmd("foo", "does foo"),
@@ -610,3 +1676,37 @@ func ExampleParserSlice() {
 	//   cmd: parser
 	//   arg: urls=[schema:relative schema:/rooted https://example.com/]
 }
+
+func ExampleGroup() {
+	app := run.MustApp("grouped", "Shows grouped help.",
+		run.String("name", "Name to greet").Flag(),
+		run.String("format", "Output format").Flag().Group("Output"),
+		run.Enabler("quiet", "Suppress output", false, true).Flag().Group("Output"),
+		run.MustCmd("list", "List things"),
+		run.MustCmd("add", "Add a remote", run.Group("Remotes")),
+		run.MustCmd("remove", "Remove a remote", run.Group("Remotes")),
+	)
+	app.Main(context.Background(), run.DefaultEnviron().WithArgs([]string{"grouped", "--help"}))
+
+	// output:
+	// Usage: grouped <command> [flags]
+	//
+	// Shows grouped help.
+	//
+	// Flags:
+	//   -h, --help      Show context-sensitive help.
+	//       --name      Name to greet
+	//
+	// Output:
+	//       --format    Output format
+	//       --quiet     Suppress output
+	//
+	// Commands:
+	//   list      List things
+	//
+	// Remotes:
+	//   add       Add a remote
+	//   remove    Remove a remote
+	//
+	// Run "grouped <command> --help" or "grouped help <command>" for more information on a command.
+}
@@ -0,0 +1,123 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// example is added by Example.
+type example struct {
+	invocation, explanation string
+}
+
+// Example adds a sample invocation to the help and generated documentation of a Command.
+// The invocation is a complete command line starting with the application name, quoted as for a shell.
+// Use ValidateExamples in tests to check that examples still parse.
+func Example(invocation, explanation string) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.examples = append(cmd.examples, example{invocation, explanation})
+		return nil
+	})
+}
+
+// HelpExample is a sample invocation of a command, as shown by help.
+type HelpExample struct {
+	Invocation  string
+	Explanation string
+}
+
+// ValidateExamples parses the invocation of every example in the application, including those of unlisted commands.
+// It reports examples that fail to parse, or that select a different command than the one declaring them.
+// Examples that show help or version information count as selecting the command they describe.
+//
+// As parsing sets the values of options, call it from tests rather than while running the application.
+func (a *Application) ValidateExamples() error {
+	var errs []error
+	var visit func(*Command)
+	visit = func(cmd *Command) {
+		for _, ex := range cmd.examples {
+			if err := a.validateExample(cmd, ex.invocation); err != nil {
+				errs = append(errs, fmt.Errorf("example %q: %w", ex.invocation, err))
+			}
+		}
+		for _, sub := range cmd.cmds {
+			sub.parent = cmd
+			visit(sub)
+		}
+	}
+	visit(&a.Command)
+	return errors.Join(errs...)
+}
+
+func (a *Application) validateExample(cmd *Command, invocation string) error {
+	args, err := splitWords(invocation)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] != a.name {
+		return fmt.Errorf("does not start with %q", a.name)
+	}
+	env := DefaultEnviron().WithArgs(args).WithVariables(Variables{}).WithInput(nil).WithStderr(io.Discard).WithTerminal(false)
+	got, err := a.Parse(env)
+	if err != nil {
+		return err
+	}
+	if got.about != nil {
+		got = got.about
+	}
+	if got != cmd {
+		return fmt.Errorf("runs %q instead of %q", strings.Join(got.path(), " "), strings.Join(cmd.path(), " "))
+	}
+	return nil
+}
+
+// splitWords splits s into words much as a shell would, honouring quotes and backslashes,
+// but without expanding variables or globs.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,84 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes returned by ExitCode.
+const (
+	ExitOK      = 0 // no error
+	ExitFailure = 1 // any error without a more specific code
+	ExitUsage   = 2 // the command line was invalid
+)
+
+// ExitCoder can be implemented by errors to choose the exit code reported by [Main].
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitCode returns the exit code for err.
+// This is ExitOK for nil, the code of the first ExitCoder in err's tree, or ExitFailure.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ec ExitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+	return ExitFailure
+}
+
+// SysExits maps err to an exit code like those in BSD's sysexits.h.
+// Usage errors report EX_USAGE (64); other errors report their ExitCode.
+// Use it with [Application.SetExitCodes].
+func SysExits(err error) int {
+	code := ExitCode(err)
+	if code == ExitUsage {
+		return 64
+	}
+	return code
+}
+
+// SetExitCodes sets a mapping from errors returned by Main to exit codes.
+// Errors returned by Main will report the mapped code from ExitCode.
+func (a *Application) SetExitCodes(mapping func(error) int) {
+	a.exitCodes = mapping
+}
+
+// SetErrorFormatter sets how Main reports errors to Environ.Stderr.
+// By default it writes Ferror and, for usage errors, a hint to run --help.
+func (a *Application) SetErrorFormatter(format func(w io.Writer, err error)) {
+	a.errorFormat = format
+}
+
+// reportError prints err to env.Stderr with the application's formatter.
+func (a *Application) reportError(env Environ, err error) {
+	w := env.Stderr
+	if a.errorFormat != nil {
+		a.errorFormat(w, err)
+		return
+	}
+	a.ferror(w, err, a.colorTheme(env, w))
+
+	var e interface{ Command() *Command }
+	if ExitCode(err) == ExitUsage && errors.As(err, &e) {
+		if cmd := e.Command(); cmd != nil && !cmd.noHelp {
+			fmt.Fprintln(w, "Run \""+strings.Join(cmd.path(), " ")+" --help\" for more information.")
+		}
+	}
+}
+
+// exitError reports an error with a mapped exit code.
+type exitError struct {
+	error
+	code int
+}
+
+func (e exitError) Unwrap() error { return e.error }
+func (e exitError) ExitCode() int { return e.code }
+
+func (HelpDisabledError) ExitCode() int { return ExitUsage }
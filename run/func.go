@@ -1,11 +1,40 @@
 package run
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 type Context struct {
 	context.Context
 	Environ
 	Command *Command
+
+	cleanups *[]func() error
+}
+
+// Defer registers fn to run after the handler and its hooks complete, such as to close files or flush logs.
+// Deferred functions run in reverse order of registration, and their errors are joined into the result of Main.
+// On other Contexts, such as those passed to a DefaultFunc or Completer, there is nothing to wait for, so fn runs immediately.
+func (c Context) Defer(fn func() error) {
+	if c.cleanups == nil {
+		_ = fn()
+		return
+	}
+	*c.cleanups = append(*c.cleanups, fn)
+}
+
+// cleanup runs the deferred functions, returning their joined errors.
+func (c Context) cleanup() error {
+	if c.cleanups == nil {
+		return nil
+	}
+	var errs []error
+	for fns := *c.cleanups; len(fns) > 0; fns = fns[:len(fns)-1] {
+		errs = append(errs, fns[len(fns)-1]())
+	}
+	*c.cleanups = nil
+	return errors.Join(errs...)
 }
 
 // Handler can be passed to (*Command).Runs, or used applied as an option in CmdOpt.
@@ -15,6 +44,9 @@ func (h Handler) applyCommand(cmd *Command) error {
 	return cmd.SetHandler(h)
 }
 
+// Middleware wraps a Handler, for example to add logging, timing, or recovery.
+type Middleware func(Handler) Handler
+
 type Param[T any] interface{ Value() T }
 
 // Handler1 adapts a func(Context, T1) for (*command).Runs.
@@ -1,151 +1,344 @@
 package run
 
 import (
-	"fmt"
+	"cmp"
 	"go/doc/comment"
-	"io"
+	"slices"
 	"strings"
 )
 
 func helpCommand(a *Application, cmd *Command) *Command {
-	return &Command{name: cmd.Name() + ".--help", handler: func(ctx Context) error {
+	return &Command{name: cmd.Name() + ".--help", about: cmd, handler: func(ctx Context) error {
 		return cmd.PrintHelp(ctx, a)
 	}}
 }
 
+// helpTopic is added by HelpTopic.
+type helpTopic struct {
+	name, text string
+}
+
+// desc returns the first line of the topic's text.
+func (t helpTopic) desc() string {
+	desc, _, _ := strings.Cut(strings.TrimSpace(t.text), "\n")
+	return desc
+}
+
+func topicCommand(cmd *Command, t helpTopic) *Command {
+	return &Command{name: "help." + t.name, about: cmd, handler: func(ctx Context) error {
+		doc := new(comment.Parser).Parse(t.text)
+		_, err := ctx.Stdout.Write((&comment.Printer{
+			TextCodePrefix: "    ",
+			TextWidth:      helpWidth(ctx.Environ, ctx.Stdout),
+		}).Text(doc))
+		return err
+	}}
+}
+
+// offersHelpCommand reports whether "help" is accepted in place of a command.
+// A command named help takes precedence.
+func (a *Application) offersHelpCommand() bool {
+	return !a.noHelp && (len(a.cmds) > 0 || len(a.topics) > 0)
+}
+
+// helpFor returns a command that shows help for the command or topic named by words, as in "app help remote add".
+// The words start at index in the command line.
+func (a *Application) helpFor(words []string, index int) (*Command, error) {
+	cmd := &a.Command
+	for j, word := range words {
+		if idx := cmd.lookupCmd(word); idx >= 0 {
+			sub := cmd.cmds[idx]
+			sub.parent = cmd
+			cmd = sub
+			continue
+		}
+		if j == len(words)-1 {
+			if t := slices.IndexFunc(cmd.topics, func(t helpTopic) bool { return t.name == word }); t >= 0 {
+				return topicCommand(cmd, cmd.topics[t]), nil
+			}
+		}
+		return nil, extraArgsError(cmd, words[j:], index+j)
+	}
+	if cmd.noHelp {
+		return nil, HelpDisabledError{ec(cmd)}
+	}
+	return helpCommand(a, cmd), nil
+}
+
 // PrintHelp writes usage information for this command to env.Stdout.
+// It wraps to the terminal width reported by env, or $COLUMNS, or 80 columns,
+// and is styled if SetColor enabled color for env.Stdout.
 func (c *Command) PrintHelp(ctx Context, a *Application) error {
-	return writeUsage(ctx.Stdout, a, c)
+	return writeUsage(ctx.Environ, a, c)
 }
 
-func writeUsage(w io.Writer, app *Application, cmd *Command) error {
-	usage := []any{"Usage:", app.name}
-	if cmd != &app.Command {
-		usage = append(usage, cmd.CommandName())
-	}
+// usageLine returns the words of the usage line for cmd, starting with its command path.
+func usageLine(app *Application, cmd *Command) []string {
+	usage := cmd.path()
 	if len(cmd.cmds) > 0 {
 		usage = append(usage, "<command>")
 	}
-	if len(cmd.flags) > 0 || (cmd == &app.Command && len(cmd.cmds) > 0) {
+	if len(cmd.flags) > 0 || (cmd == &app.Command && (len(cmd.cmds) > 0 || len(app.builtin.flags) > 0 || app.version != nil)) {
 		usage = append(usage, "[flags]")
 	}
 	for _, arg := range cmd.args {
 		usage = append(usage, arg.describe())
 	}
-	fmt.Fprintln(w, usage...)
+	return usage
+}
 
-	if len(cmd.desc) > 0 {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, cmd.desc)
+func writeUsage(env Environ, app *Application, cmd *Command) error {
+	renderer := app.helpRenderer
+	if renderer == nil {
+		renderer = TextHelp{}
 	}
-	if len(cmd.detail) > 0 {
-		fmt.Fprintln(w)
-		_, _ = w.Write((&comment.Printer{
-			TextCodePrefix: "    ",
-			TextWidth:      80,
-		}).Text(new(comment.Parser).Parse(cmd.detail)))
-	}
-
-	if len(cmd.args) > 0 {
-		args := makeTable("Arguments:")
-		for _, arg := range cmd.args {
-			args.Add(arg.describe(), arg.option.description())
-			for _, also := range arg.option.seeAlso() {
-				if cmd != also {
-					args.Add("", fmt.Sprintf("(See %s %s --help)", app.name, also.name))
-				}
-			}
+	help := app.Help(cmd)
+	help.Width = helpWidth(env, env.Stdout)
+	help.Theme = app.colorTheme(env, env.Stdout)
+	return renderer.RenderHelp(env.Stdout, help)
+}
 
-		}
-		args.Write(w)
+// Help is a structured description of a command, as shown by --help.
+type Help struct {
+	Command     *Command
+	Usage       []string     // Usage holds the words of the usage line, starting with the command path.
+	Description string       // Description is the command's short description.
+	Details     *comment.Doc // Details holds the parsed details of the command, or nil.
+	Sections    []HelpSection
+	Examples    []HelpExample
+	SeeAlso     []*Command // SeeAlso lists related commands, such as its parent.
+	Footer      string     // Footer is a closing hint, if any.
+	Width       int        // Width is the number of columns to wrap to, or 0 for the default of 80.
+	Theme       *Theme     // Theme styles the output, or is nil for plain text.
+}
+
+// HelpSectionKind identifies what a HelpSection lists.
+type HelpSectionKind int
+
+const (
+	HelpArgs HelpSectionKind = iota
+	HelpFlags
+	HelpCommands
+	HelpTopics
+)
+
+// HelpSection is a titled list of arguments, flags, commands, or topics.
+// Sections of the same kind are adjacent, with ungrouped entries first.
+type HelpSection struct {
+	Kind    HelpSectionKind
+	Title   string
+	Entries []HelpEntry
+}
+
+// HelpEntry describes an argument, flag, or command in a HelpSection.
+type HelpEntry struct {
+	Name        string
+	Description string
+	Choices     []Choice   // Choices lists the names the option accepts, unless Name shows them.
+	SeeAlso     []*Command // SeeAlso lists other commands documenting this option.
+	Default     string     // Default describes the option's default, unless Name shows it.
+	Env         string     // Env names the environment variable that supplies the option, if any.
+	Required    bool       // Required is set for flags that must be provided.
+
+	Arg     *Arg     // Arg is set for entries in HelpArgs sections.
+	Flag    *Flag    // Flag is set for entries in HelpFlags sections.
+	Command *Command // Command is set for entries in HelpCommands sections.
+}
+
+// Trailer returns the entry's default, environment variable, and constraints,
+// formatted as "(default: 30s, env: $APP_TIMEOUT, required)", or "" if there are none.
+func (e HelpEntry) Trailer() string {
+	var notes []string
+	if e.Default != "" {
+		notes = append(notes, "default: "+e.Default)
 	}
+	if e.Env != "" {
+		notes = append(notes, "env: $"+e.Env)
+	}
+	if e.Required {
+		notes = append(notes, "required")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(notes, ", ") + ")"
+}
 
-	// commands have help at least help flags, unless suppressed
-	if len(cmd.flags) > 0 || !cmd.noHelp {
-		anyRuneString := !app.noHelp // help includes a rune+string
-		for _, flag := range cmd.flags {
-			if flag.rune != 0 && flag.string != "" {
-				anyRuneString = true
+// withTrailer returns the entry's description followed by its Trailer.
+func withTrailer(e HelpEntry) string {
+	return strings.TrimSpace(e.Description + " " + e.Trailer())
+}
+
+// DefaultsStyle chooses where help shows the defaults of flags.
+type DefaultsStyle int
+
+const (
+	DefaultsInline  DefaultsStyle = iota // after the flag's name, as in --timeout=30s
+	DefaultsTrailer                      // after the description, as in (default: 30s)
+)
+
+// SetDefaultsStyle sets where help shows the defaults of flags.
+// Defaults of arguments, environment variables, and constraints always follow the description.
+func (a *Application) SetDefaultsStyle(style DefaultsStyle) {
+	a.defaultsStyle = style
+}
+
+// helpFlag describes the built-in -h, --help flag.
+var helpFlag = Flag{rune: 'h', string: "help"}
+
+// Help returns a structured description of cmd for rendering by a HelpRenderer.
+func (a *Application) Help(cmd *Command) *Help {
+	h := &Help{
+		Command:     cmd,
+		Usage:       usageLine(a, cmd),
+		Description: cmd.desc,
+	}
+	if cmd.detail != "" {
+		h.Details = new(comment.Parser).Parse(cmd.detail)
+	}
+
+	seeAlso := func(opt Option) []*Command {
+		var see []*Command
+		for _, also := range opt.seeAlso() {
+			if also != cmd {
+				see = append(see, also)
 			}
 		}
+		return see
+	}
+	section := func(kind HelpSectionKind, title string) *HelpSection {
+		for i := range h.Sections {
+			if h.Sections[i].Kind == kind && h.Sections[i].Title == title {
+				return &h.Sections[i]
+			}
+		}
+		h.Sections = append(h.Sections, HelpSection{Kind: kind, Title: title})
+		return &h.Sections[len(h.Sections)-1]
+	}
+	// ungrouped sections precede groups; sections are then ordered by kind, and empty ones removed
+	section(HelpArgs, "Arguments")
+	section(HelpFlags, "Flags")
+	section(HelpCommands, "Commands")
 
-		flags := makeTable("Flags:")
-		flags.Max = 22
-		if !cmd.noHelp {
-			flags.Add("-h, --help", "Show context-sensitive help.")
+	for i := range cmd.args {
+		e := argEntry(&cmd.args[i])
+		e.SeeAlso = seeAlso(e.Arg.option)
+		args := section(HelpArgs, "Arguments")
+		args.Entries = append(args.Entries, e)
+	}
+
+	// commands have help at least help flags, unless suppressed
+	if !cmd.noHelp {
+		flags := section(HelpFlags, "Flags")
+		flags.Entries = append(flags.Entries, HelpEntry{
+			Name:        helpFlag.describe(false),
+			Description: "Show context-sensitive help.",
+			Flag:        &helpFlag,
+		})
+	}
+	if cmd == &a.Command && a.version != nil {
+		flags := section(HelpFlags, "Flags")
+		flags.Entries = append(flags.Entries, HelpEntry{
+			Name:        versionFlag.describe(false),
+			Description: "Show version information.",
+			Flag:        &versionFlag,
+		})
+	}
+	addFlag := func(group string, flag *Flag) {
+		if flag.hidden {
+			return
 		}
-		for _, flag := range cmd.flags {
-			var names []string
-			if flag.rune != 0 {
-				names = append(names, "-"+string(flag.rune))
-			}
-			if flag.string != "" {
-				names = append(names, "--"+flag.string)
-			}
-			name := strings.Join(names, ", ")
-			if anyRuneString && flag.rune == 0 {
-				name = "    " + name
-			}
-			if flag.defaultSet {
-				name += "=" + flag.defaultString
-			} else if p := flag.hint; p != "" {
-				name += "=" + p
-			}
+		e := a.flagEntry(flag)
+		e.SeeAlso = seeAlso(flag.option)
+		flags := section(HelpFlags, group)
+		flags.Entries = append(flags.Entries, e)
+	}
+	for i := range cmd.flags {
+		addFlag(cmp.Or(cmd.flags[i].group, "Flags"), &cmd.flags[i])
+	}
+	for _, flag := range cmd.inheritedFlags() {
+		addFlag("Global Flags", flag)
+	}
+	for i := range a.builtin.flags {
+		if cmd == &a.Command {
+			addFlag("Flags", &a.builtin.flags[i])
+		} else {
+			addFlag("Global Flags", &a.builtin.flags[i])
+		}
+	}
 
-			flags.Add(name, flag.option.description())
-			for _, also := range flag.option.seeAlso() {
-				if cmd != also {
-					flags.Add("", fmt.Sprintf("(See %s %s --help)", app.name, also.name))
-				}
-			}
+	for _, sub := range cmd.cmds {
+		if !sub.unlisted {
+			cmds := section(HelpCommands, cmp.Or(sub.group, "Commands"))
+			cmds.Entries = append(cmds.Entries, HelpEntry{
+				Name:        sub.name,
+				Description: sub.desc,
+				Command:     sub,
+			})
 		}
-		flags.Write(w)
+	}
+	for _, t := range cmd.topics {
+		topics := section(HelpTopics, "Topics")
+		topics.Entries = append(topics.Entries, HelpEntry{Name: t.name, Description: t.desc()})
+	}
+	slices.SortStableFunc(h.Sections, func(a, b HelpSection) int { return cmp.Compare(a.Kind, b.Kind) })
+	h.Sections = slices.DeleteFunc(h.Sections, func(s HelpSection) bool { return len(s.Entries) == 0 })
+	for _, ex := range cmd.examples {
+		h.Examples = append(h.Examples, HelpExample{Invocation: ex.invocation, Explanation: ex.explanation})
 	}
 
+	var footer []string
 	if len(cmd.cmds) > 0 {
-		cmds := makeTable("Commands:")
-		for _, cmd := range cmd.cmds {
-			if !cmd.unlisted {
-				cmds.Add(cmd.name, cmd.desc)
-			}
+		line := "Run \"" + a.name + " <command> --help\""
+		if a.offersHelpCommand() && a.lookupCmd("help") < 0 {
+			words := append([]string{a.name, "help"}, cmd.path()[1:]...)
+			line += " or \"" + strings.Join(words, " ") + " <command>\""
 		}
-		cmds.Write(w)
-		fmt.Fprintln(w, "\nRun \""+app.name+" <command> --help\" for more information on a command.")
+		footer = append(footer, line+" for more information on a command.")
 	}
+	if len(cmd.topics) > 0 {
+		words := append([]string{a.name, "help"}, cmd.path()[1:]...)
+		footer = append(footer, "Run \""+strings.Join(words, " ")+" <topic>\" for more information on a topic.")
+	}
+	h.Footer = strings.Join(footer, "\n")
 
-	_, err := w.Write(nil)
-	return err
-}
-
-func makeTable(name string) table {
-	return table{Name: name, Min: 6, Max: 12, Pad: 3}
+	if cmd.parent != nil {
+		h.SeeAlso = append(h.SeeAlso, cmd.parent)
+	}
+	for _, opt := range cmd.options() {
+		for _, see := range seeAlso(opt) {
+			if !slices.Contains(h.SeeAlso, see) {
+				h.SeeAlso = append(h.SeeAlso, see)
+			}
+		}
+	}
+	return h
 }
 
-type table struct {
-	Name     string
-	Items    [][2]string
-	Min, Max int
-	Pad      int
+// argEntry describes arg for help.
+func argEntry(arg *Arg) HelpEntry {
+	return HelpEntry{
+		Name:        arg.describe(),
+		Description: arg.option.description(),
+		Choices:     arg.option.choices(),
+		Default:     redact(arg.defaultString, arg.option.sensitive()),
+		Arg:         arg,
+	}
 }
 
-func (t *table) Add(col1, col2 string) { t.Items = append(t.Items, [2]string{col1, col2}) }
-
-func (t *table) Write(w io.Writer) {
-	longest := t.Min
-	for _, it := range t.Items {
-		longest = max(longest, len(it[0]))
-	}
-	if t.Max > 0 {
-		longest = min(longest, t.Max)
+// flagEntry describes flag for help, showing its default as set by SetDefaultsStyle.
+func (a *Application) flagEntry(flag *Flag) HelpEntry {
+	inline := a.defaultsStyle == DefaultsInline
+	e := HelpEntry{
+		Name:        flag.describe(inline),
+		Description: flag.option.description(),
+		Choices:     flag.listedChoices(inline),
+		Env:         flag.env,
+		Required:    flag.required,
+		Flag:        flag,
 	}
-
-	fmt.Fprintln(w)
-	fmt.Fprintln(w, t.Name)
-	for _, it := range t.Items {
-		if len(it[0]) > longest {
-			fmt.Fprintf(w, "  %s\n  %-*s %s\n", it[0], longest+t.Pad, "", it[1])
-		} else {
-			fmt.Fprintf(w, "  %-*s %s\n", longest+t.Pad, it[0], it[1])
-		}
+	if _, ok := flag.inlineDefault(inline); !ok {
+		e.Default = flag.defaultDesc()
 	}
+	return e
 }
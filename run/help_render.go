@@ -0,0 +1,228 @@
+package run
+
+import (
+	"cmp"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// HelpRenderer writes help for a command.
+type HelpRenderer interface {
+	RenderHelp(w io.Writer, help *Help) error
+}
+
+// SetHelpRenderer sets how help is written. By default it uses TextHelp.
+func (a *Application) SetHelpRenderer(r HelpRenderer) {
+	a.helpRenderer = r
+}
+
+// TextHelp renders help as plain text with aligned tables.
+// Zero values use the defaults shown.
+type TextHelp struct {
+	MinWidth     int // MinWidth is the minimum width of the name column (6).
+	MaxWidth     int // MaxWidth is the width beyond which argument and command names wrap (12).
+	MaxFlagWidth int // MaxFlagWidth is the width beyond which flag names wrap (22).
+	Padding      int // Padding separates names from descriptions (3).
+}
+
+func (t TextHelp) RenderHelp(out io.Writer, help *Help) error {
+	w := &errWriter{w: out}
+	width := cmp.Or(help.Width, defaultWidth)
+	theme := help.Theme
+	fmt.Fprintln(w, theme.heading("Usage:")+" "+strings.Join(help.Usage, " "))
+
+	if len(help.Description) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, help.Description)
+	}
+	if help.Details != nil {
+		fmt.Fprintln(w)
+		_, _ = w.Write((&comment.Printer{
+			TextCodePrefix: "    ",
+			TextWidth:      width,
+		}).Text(help.Details))
+	}
+
+	// align flags without short names with those that have them
+	indent := false
+	for _, sec := range help.Sections {
+		for _, e := range sec.Entries {
+			if e.Flag != nil && e.Flag.rune != 0 && e.Flag.string != "" {
+				indent = true
+			}
+		}
+	}
+
+	for i := 0; i < len(help.Sections); {
+		// topics align with commands
+		kind := help.Sections[i].Kind
+		aligned := func(k HelpSectionKind) bool { return k == kind || (kind == HelpCommands && k == HelpTopics) }
+		tabs := tables{
+			Min: cmp.Or(t.MinWidth, 6),
+			Max: cmp.Or(t.MaxWidth, 12),
+			Pad: cmp.Or(t.Padding, 3),
+		}
+		if kind == HelpFlags {
+			tabs.Max = cmp.Or(t.MaxFlagWidth, 22)
+		}
+		for ; i < len(help.Sections) && aligned(help.Sections[i].Kind); i++ {
+			sec := help.Sections[i]
+			tab := table{Name: theme.heading(sec.Title + ":")}
+			for _, e := range sec.Entries {
+				name := e.Name
+				if e.Flag != nil {
+					if indent && e.Flag.rune == 0 {
+						name = "    " + name
+					}
+					name = theme.flag(name)
+				}
+				tab.Add(name, withTrailer(e))
+				if len(e.Choices) > 0 {
+					width := 0
+					for _, c := range e.Choices {
+						width = max(width, displayWidth(c.Name))
+					}
+					for _, c := range e.Choices {
+						tab.Add("", strings.TrimRight("  "+padRight(c.Name, width)+"   "+c.Desc, " "))
+					}
+				}
+				for _, also := range e.SeeAlso {
+					tab.Add("", fmt.Sprintf("(See %s --help)", strings.Join(also.path(), " ")))
+				}
+			}
+			tabs.Tables = append(tabs.Tables, tab)
+		}
+		tabs.Write(w, width)
+	}
+
+	if len(help.Examples) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, theme.heading("Examples:"))
+		for i, ex := range help.Examples {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, "  "+ex.Invocation)
+			for _, line := range wrapText(ex.Explanation, max(width-6, minWrap)) {
+				if line != "" {
+					fmt.Fprintln(w, "      "+line)
+				}
+			}
+		}
+	}
+
+	if help.Footer != "" {
+		fmt.Fprintln(w, "\n"+help.Footer)
+	}
+
+	return w.err
+}
+
+// errWriter writes to w until the first error, which it records.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+// TemplateHelp renders help by executing tmpl with a *Help.
+// The template can call text to format Details as plain text wrapped to a width, as in {{text .Width .Details}}.
+func TemplateHelp(tmpl *template.Template) HelpRenderer {
+	return templateHelp{tmpl}
+}
+
+// HelpFuncs returns the functions available to templates used with TemplateHelp.
+// Add them to a template with Funcs before parsing it.
+func HelpFuncs() template.FuncMap {
+	return template.FuncMap{
+		"text": func(width int, d *comment.Doc) string {
+			if d == nil {
+				return ""
+			}
+			return string((&comment.Printer{TextCodePrefix: "    ", TextWidth: cmp.Or(width, defaultWidth)}).Text(d))
+		},
+		"join": strings.Join,
+	}
+}
+
+type templateHelp struct{ tmpl *template.Template }
+
+func (t templateHelp) RenderHelp(w io.Writer, help *Help) error {
+	return t.tmpl.Execute(w, help)
+}
+
+type tables struct {
+	Tables        []table
+	Min, Max, Pad int
+}
+
+// Write writes each non-empty table, aligning their columns, and wrapping to width.
+func (t *tables) Write(w io.Writer, width int) {
+	longest := t.Min
+	for _, tab := range t.Tables {
+		for _, it := range tab.Items {
+			longest = max(longest, displayWidth(it[0]))
+		}
+	}
+	for _, tab := range t.Tables {
+		if len(tab.Items) > 0 {
+			tab.Min, tab.Max, tab.Pad = min(longest, t.Max), t.Max, t.Pad
+			tab.Write(w, width)
+		}
+	}
+}
+
+type table struct {
+	Name     string
+	Items    [][2]string
+	Min, Max int
+	Pad      int
+}
+
+func (t *table) Add(col1, col2 string) { t.Items = append(t.Items, [2]string{col1, col2}) }
+
+// minWrap is the narrowest description column worth wrapping to.
+const minWrap = 20
+
+// Write writes the table, wrapping descriptions to width with a hanging indent.
+func (t *table) Write(w io.Writer, width int) {
+	longest := t.Min
+	for _, it := range t.Items {
+		longest = max(longest, displayWidth(it[0]))
+	}
+	if t.Max > 0 {
+		longest = min(longest, t.Max)
+	}
+
+	indent := 2 + longest + t.Pad + 1
+	wrapAt := width - indent
+	if wrapAt < minWrap {
+		wrapAt = 0
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, t.Name)
+	for _, it := range t.Items {
+		name := it[0]
+		if displayWidth(name) > longest {
+			fmt.Fprintf(w, "  %s\n", name)
+			name = ""
+		}
+		lines := wrapText(it[1], wrapAt)
+		fmt.Fprintf(w, "  %s %s\n", padRight(name, longest+t.Pad), lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "%*s%s\n", indent, "", line)
+		}
+	}
+}
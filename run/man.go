@@ -0,0 +1,318 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ManCommand returns an unlisted command that writes man pages for app into a directory.
+// Add it to app with SetCommands, and run it as "app gen-man <dir>".
+func ManCommand(app *Application) *Command {
+	dir := File("dir", "Directory to write man pages into")
+	return MustCmd("gen-man", "Generate man pages", Unlisted(),
+		dir.Arg("dir"),
+		Handler1(func(_ Context, dir string) error { return app.GenMan(dir) }, dir),
+	)
+}
+
+// GenMan writes a section 1 man page for each listed command in app into dir.
+// Pages are named after the command path, such as app-remote-add.1.
+func (a *Application) GenMan(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return a.walk(func(cmd *Command) error {
+		var buf bytes.Buffer
+		if err := a.WriteMan(&buf, cmd); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, manName(cmd)+".1"), buf.Bytes(), 0o644)
+	})
+}
+
+// walk calls fn for a and each listed command below it, parents before children.
+func (a *Application) walk(fn func(*Command) error) error {
+	var visit func(*Command) error
+	visit = func(cmd *Command) error {
+		if err := fn(cmd); err != nil {
+			return err
+		}
+		for _, sub := range cmd.cmds {
+			if !sub.unlisted {
+				sub.parent = cmd
+				if err := visit(sub); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return visit(&a.Command)
+}
+
+func manName(cmd *Command) string {
+	return strings.Join(cmd.path(), "-")
+}
+
+// WriteMan writes a roff man page for cmd to w.
+// The output depends only on the command tree, so it is suitable for golden tests.
+func (a *Application) WriteMan(w io.Writer, cmd *Command) error {
+	r := &roff{}
+	name := manName(cmd)
+	r.line(".TH %s 1 \"\" %s %s", r.quote(strings.ToUpper(name)), r.quote(a.name), r.quote(a.name+" Manual"))
+
+	r.line(".SH NAME")
+	if cmd.desc != "" {
+		r.line("%s \\- %s", r.escape(name), r.escape(cmd.desc))
+	} else {
+		r.line("%s", r.escape(name))
+	}
+
+	r.line(".SH SYNOPSIS")
+	usage := usageLine(a, cmd)
+	path := len(cmd.path())
+	r.line(".B %s", r.escape(strings.Join(usage[:path], " ")))
+	if len(usage) > path {
+		r.line("%s", r.escape(strings.Join(usage[path:], " ")))
+	}
+
+	if cmd.desc != "" || cmd.detail != "" {
+		r.line(".SH DESCRIPTION")
+		if cmd.desc != "" {
+			r.line("%s", r.escape(cmd.desc))
+		}
+		if cmd.detail != "" {
+			r.doc(new(comment.Parser).Parse(cmd.detail))
+		}
+	}
+
+	if len(cmd.args) > 0 {
+		r.line(".SH ARGUMENTS")
+		for _, arg := range cmd.args {
+			r.item(r.escape(arg.describe()), withTrailer(argEntry(&arg)))
+			r.choices(arg.option.choices())
+		}
+	}
+
+	flags := slices.DeleteFunc(a.acceptedFlags(cmd), func(f *Flag) bool { return f.hidden })
+	version := cmd == &a.Command && a.version != nil
+	if len(flags) > 0 || !cmd.noHelp || version {
+		r.line(".SH OPTIONS")
+		if !cmd.noHelp {
+			r.item("\\fB\\-h\\fR, \\fB\\-\\-help\\fR", "Show context-sensitive help.")
+		}
+		if version {
+			r.item("\\fB\\-V\\fR, \\fB\\-\\-version\\fR", "Show version information.")
+		}
+		for _, flag := range flags {
+			e := a.flagEntry(flag)
+			r.item(r.flag(flag, a.defaultsStyle == DefaultsInline), withTrailer(e))
+			r.choices(e.Choices)
+		}
+	}
+
+	var cmds []*Command
+	for _, sub := range cmd.cmds {
+		if !sub.unlisted {
+			cmds = append(cmds, sub)
+		}
+	}
+	if len(cmds) > 0 {
+		r.line(".SH COMMANDS")
+		for _, sub := range cmds {
+			r.item("\\fB"+r.escape(sub.name)+"\\fR", sub.desc)
+		}
+	}
+
+	var envs []*Flag
+	for _, flag := range flags {
+		if flag.env != "" {
+			envs = append(envs, flag)
+		}
+	}
+	if len(envs) > 0 {
+		r.line(".SH ENVIRONMENT")
+		for _, flag := range envs {
+			r.item("\\fB"+r.escape(flag.env)+"\\fR", "Default for "+flag.name()+".")
+		}
+	}
+
+	if len(cmd.examples) > 0 {
+		r.line(".SH EXAMPLES")
+		for _, ex := range cmd.examples {
+			r.item("\\fB"+r.escape(ex.invocation)+"\\fR", ex.explanation)
+		}
+	}
+
+	var also []string
+	if cmd.parent != nil {
+		also = append(also, manName(cmd.parent))
+	}
+	for _, sub := range cmds {
+		also = append(also, manName(sub))
+	}
+	for _, opt := range cmd.options() {
+		for _, see := range opt.seeAlso() {
+			if see != cmd {
+				also = append(also, manName(see))
+			}
+		}
+	}
+	if len(also) > 0 {
+		r.line(".SH SEE ALSO")
+		seen := map[string]bool{}
+		var refs []string
+		for _, name := range also {
+			if !seen[name] {
+				seen[name] = true
+				refs = append(refs, "\\fB"+r.escape(name)+"\\fR(1)")
+			}
+		}
+		r.line("%s", strings.Join(refs, ", "))
+	}
+
+	_, err := w.Write(r.buf.Bytes())
+	return err
+}
+
+// options returns the options of cmd's args and flags.
+func (c *Command) options() []Option {
+	opts := make([]Option, 0, len(c.args)+len(c.flags))
+	for _, arg := range c.args {
+		opts = append(opts, arg.option)
+	}
+	for _, flag := range c.flags {
+		opts = append(opts, flag.option)
+	}
+	return opts
+}
+
+// roff accumulates a man page.
+type roff struct {
+	buf bytes.Buffer
+}
+
+func (r *roff) line(format string, args ...any) {
+	fmt.Fprintf(&r.buf, format, args...)
+	r.buf.WriteByte('\n')
+}
+
+// item writes a tagged paragraph.
+func (r *roff) item(tag, desc string) {
+	r.line(".TP")
+	r.line("%s", tag)
+	if desc != "" {
+		r.line("%s", r.escape(desc))
+	}
+}
+
+// choices writes an indented list of the names an option accepts.
+func (r *roff) choices(choices []Choice) {
+	if len(choices) == 0 {
+		return
+	}
+	r.line(".RS")
+	for _, c := range choices {
+		r.item("\\fB"+r.escape(c.Name)+"\\fR", c.Desc)
+	}
+	r.line(".RE")
+}
+
+// flag formats the names and placeholder of a flag, showing its default if inline is set.
+func (r *roff) flag(f *Flag, inline bool) string {
+	var names []string
+	if f.rune != 0 {
+		names = append(names, "\\fB"+r.escape("-"+string(f.rune))+"\\fR")
+	}
+	if f.string != "" {
+		names = append(names, "\\fB"+r.escape("--"+f.string)+"\\fR")
+	}
+	tag := strings.Join(names, ", ")
+	def, ok := f.inlineDefault(inline)
+	switch {
+	case ok:
+		tag += "=" + r.escape(def)
+	case inlineChoices(f.choices()) != "":
+		tag += "=" + r.escape(inlineChoices(f.choices()))
+	case f.hint != "":
+		tag += "=\\fI" + r.escape(f.hint) + "\\fR"
+	}
+	return tag
+}
+
+// escape makes s safe as roff text.
+func (r *roff) escape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// quote makes s a single roff macro argument.
+func (r *roff) quote(s string) string {
+	return `"` + strings.ReplaceAll(r.escape(s), `"`, `\(dq`) + `"`
+}
+
+// doc writes parsed details.
+func (r *roff) doc(d *comment.Doc) {
+	for _, block := range d.Content {
+		switch b := block.(type) {
+		case *comment.Heading:
+			r.line(".SS %s", r.quote(r.text(b.Text)))
+		case *comment.Paragraph:
+			r.line(".PP")
+			r.line("%s", r.text(b.Text))
+		case *comment.Code:
+			r.line(".PP")
+			r.line(".RS 4")
+			r.line(".nf")
+			for _, code := range strings.Split(strings.TrimSuffix(b.Text, "\n"), "\n") {
+				r.line("%s", r.escape(code))
+			}
+			r.line(".fi")
+			r.line(".RE")
+		case *comment.List:
+			for i, item := range b.Items {
+				bullet := `\(bu`
+				if item.Number != "" {
+					bullet = fmt.Sprintf("%d.", i+1)
+				}
+				for j, content := range item.Content {
+					if p, ok := content.(*comment.Paragraph); ok {
+						if j == 0 {
+							r.line(".IP %s 4", bullet)
+						} else {
+							r.line(".IP \"\" 4")
+						}
+						r.line("%s", r.text(p.Text))
+					}
+				}
+			}
+		}
+	}
+}
+
+// text formats inline text, joining lines into a single roff line.
+func (r *roff) text(t []comment.Text) string {
+	var sb strings.Builder
+	for _, t := range t {
+		switch t := t.(type) {
+		case comment.Plain:
+			sb.WriteString(r.escape(strings.ReplaceAll(string(t), "\n", " ")))
+		case comment.Italic:
+			sb.WriteString(`\fI` + r.escape(strings.ReplaceAll(string(t), "\n", " ")) + `\fR`)
+		case *comment.Link:
+			sb.WriteString(r.text(t.Text))
+		case *comment.DocLink:
+			sb.WriteString(r.text(t.Text))
+		}
+	}
+	return strings.TrimLeft(sb.String(), " ")
+}
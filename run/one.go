@@ -10,14 +10,22 @@ type flagOnly[T any] struct {
 	desc  string
 	value *T
 	seen  func() (T, error)
+	clear func() // clear restores the initial value and state before another parse
 	see   []*Command
+	src   Source
 }
 
 func (o *flagOnly[T]) description() string           { return o.desc }
 func (o *flagOnly[T]) seeAlso() []*Command           { return o.see }
 func (o *flagOnly[T]) setSeeAlso(cmds ...*Command)   { o.see = cmds }
+func (o *flagOnly[T]) source() Source                { return o.src }
+func (o *flagOnly[T]) setSource(src Source)          { o.src = src }
+func (o *flagOnly[T]) reset()                        { o.src = Source{}; o.clear() }
 func (o *flagOnly[T]) okValues() []string            { return nil }
 func (o *flagOnly[T]) okPrefix() string              { return "" }
+func (o *flagOnly[T]) choices() []Choice             { return nil }
+func (o *flagOnly[T]) completer() Completer          { return nil }
+func (o *flagOnly[T]) sensitive() bool               { return false }
 func (o *flagOnly[T]) parseDefault(arg string) error { return o.got(false) }
 func (o *flagOnly[T]) parseFlag() error              { return o.got(true) }
 
@@ -50,6 +58,12 @@ func (o *flagOnly[T]) Slice() Param[[]T] {
 	return sliceOf[T]{o.value}
 }
 
+// Presence returns a Param that produces its value and whether the user provided it.
+// This can be used to distinguish provided values from defaults.
+func (o *flagOnly[T]) Presence() Param[Presence[T]] {
+	return presenceOf[T]{o.value, o}
+}
+
 var errRepeated = errors.New("repeated")
 
 // Enabler creates an option that defaults to unseen, gets set to seen, and errors on repeat.
@@ -60,7 +74,7 @@ func Enabler[T any](name, desc string, unseen, seen T) *flagOnly[T] {
 
 // EnablerVar creates an option that defaults to unseen, gets set to seen, and errors on repeat.
 func EnablerVar[T any](p *T, name, desc string, seen T) *flagOnly[T] {
-	enabled := false
+	unseen, enabled := *p, false
 	return &flagOnly[T]{
 		name:  name,
 		desc:  desc,
@@ -72,6 +86,7 @@ func EnablerVar[T any](p *T, name, desc string, seen T) *flagOnly[T] {
 			enabled = true
 			return seen, nil
 		},
+		clear: func() { *p, enabled = unseen, false },
 	}
 }
 
@@ -93,6 +108,7 @@ func TogglerVar[T any](p *T, name, desc string, seen T) *flagOnly[T] {
 			n++
 			return toggle[n%2], nil
 		},
+		clear: func() { *p, n = toggle[0], 0 },
 	}
 }
 
@@ -104,7 +120,8 @@ func Accumulator[T cmp.Ordered](name, desc string, initial, increment T) *flagOn
 
 // AccumulatorVar creates an option that starts as initial, and adds increment every time it is seen.
 func AccumulatorVar[T cmp.Ordered](p *T, name, desc string, increment T) *flagOnly[T] {
-	v := *p
+	initial := *p
+	v := initial
 	return &flagOnly[T]{
 		name:  name,
 		desc:  desc,
@@ -113,5 +130,6 @@ func AccumulatorVar[T cmp.Ordered](p *T, name, desc string, increment T) *flagOn
 			v += increment
 			return v, nil
 		},
+		clear: func() { *p, v = initial, initial },
 	}
 }
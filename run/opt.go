@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -12,27 +13,47 @@ type option[T any] struct {
 	name     string
 	desc     string
 	value    *T
+	initial  T // initial is restored before each parse
 	parse    func(string) (T, error)
 	prefixOK string   // set to - to allow -[^-]+, or -- to allow --.+ in arg context
 	strOK    []string // include unusual values such as - to allow them in arg context
+	named    []Choice // the names accepted by NamedOf and similar
+	complete Completer
+	secret   bool // hide the value, as set by Sensitive
 	see      []*Command
+	src      Source
 }
 
 func (o *option[T]) description() string               { return o.desc }
 func (o *option[T]) seeAlso() []*Command               { return o.see }
 func (o *option[T]) setSeeAlso(cmds ...*Command)       { o.see = cmds }
+func (o *option[T]) source() Source                    { return o.src }
+func (o *option[T]) setSource(src Source)              { o.src = src }
+func (o *option[T]) reset()                            { o.src, *o.value = Source{}, o.initial }
 func (o *option[T]) okValues() []string                { return o.strOK }
 func (o *option[T]) okPrefix() string                  { return o.prefixOK }
+func (o *option[T]) choices() []Choice                 { return o.named }
+func (o *option[T]) completer() Completer              { return o.complete }
+func (o *option[T]) sensitive() bool                   { return o.secret }
 func (o *option[T]) parseDefault(arg string) error     { return o.got(arg, false) }
 func (o *option[T]) parseInline(arg string) error      { return o.got(arg, true) }
 func (o *option[T]) parseValue(arg string) error       { return o.got(arg, true) }
 func (o *option[T]) withPrefixOK(ok string) *option[T] { o.prefixOK = ok; return o }
 func (o *option[T]) withStrOK(ok []string) *option[T]  { o.strOK = ok; return o }
+func (o *option[T]) withChoices(c []Choice) *option[T] { o.named = c; return o }
+
+// Complete sets a function that offers values for this option to shell completion; see CompletionCommand.
+// Without one, completion offers the names accepted by NamedOf and similar, and file names for File options.
+func (o *option[T]) Complete(c Completer) *option[T] { o.complete = c; return o }
+
+// Sensitive hides this option's value, such as a password, from help, errors, Debug, and DumpConfig, and from prompts.
+// See also Secret and FileFlag.
+func (o *option[T]) Sensitive() *option[T] { o.secret = true; return o }
 
 func (o *option[T]) got(arg string, real bool) error {
 	v, err := o.parse(arg)
 	if err != nil {
-		return err
+		return redactError(err, arg, o.secret)
 	}
 	*o.value = v
 	return nil
@@ -63,6 +84,12 @@ func (o *option[T]) Slice() Param[[]T] {
 	return sliceOf[T]{o.value}
 }
 
+// Presence returns a Param that produces its value and whether the user provided it.
+// This can be used to distinguish provided values from defaults.
+func (o *option[T]) Presence() Param[Presence[T]] {
+	return presenceOf[T]{o.value, o}
+}
+
 type sliceOf[T any] struct{ value *T }
 
 func (s sliceOf[T]) Value() []T { return []T{*s.value} }
@@ -89,12 +116,38 @@ func StringLikeVar[T ~string](p *T, name, desc string) *option[T] {
 	return ParserVar(p, name, desc, parse)
 }
 
+// NamedValue maps a name to a value for NamedOf and similar.
+// Help lists each name with its Desc.
 type NamedValue[T any] struct {
 	Name  string
 	Desc  string
 	Value T
 }
 
+// Choice describes a name accepted by an option, as listed in help.
+type Choice struct {
+	Name string
+	Desc string
+}
+
+// maxInlineChoices is the most choices shown inline in help, as in --format=text|json|yaml.
+const maxInlineChoices = 7
+
+// inlineChoices returns choices formatted as a|b|c, if they are few and undescribed.
+func inlineChoices(choices []Choice) string {
+	if len(choices) == 0 || len(choices) > maxInlineChoices {
+		return ""
+	}
+	names := make([]string, len(choices))
+	for i, c := range choices {
+		if c.Desc != "" {
+			return ""
+		}
+		names[i] = c.Name
+	}
+	return strings.Join(names, "|")
+}
+
 // StringOf creates an option that stores a string-like value from the provided list.
 // This is suitable for small to medium sets of string-like names.
 func StringOf[T ~string](name, desc string, names ...T) *option[T] {
@@ -116,14 +169,14 @@ func StringVarOf[T ~string](p *T, name, desc string, names ...T) *option[T] {
 // This is suitable for small to medium sets of names.
 func NamedOf[T any](name, desc string, mapping []NamedValue[T]) *option[T] {
 	mapping = slices.Clone(mapping)
-	return Parser(name, desc, (namedValues[T])(mapping).parse)
+	return Parser(name, desc, (namedValues[T])(mapping).parse).withChoices((namedValues[T])(mapping).choices())
 }
 
 // NamedVarOf creates an option that stores any type of value, looked up from the provided mapping.
 // This is suitable for small to medium sets of names.
 func NamedVarOf[T any](p *T, name, desc string, mapping []NamedValue[T]) *option[T] {
 	mapping = slices.Clone(mapping)
-	return ParserVar(p, name, desc, (namedValues[T])(mapping).parse)
+	return ParserVar(p, name, desc, (namedValues[T])(mapping).parse).withChoices((namedValues[T])(mapping).choices())
 }
 
 type namedValues[T any] []NamedValue[T]
@@ -138,6 +191,14 @@ func (nvs namedValues[T]) parse(arg string) (zero T, err error) {
 	return T(nvs[pos].Value), nil
 }
 
+func (nvs namedValues[T]) choices() []Choice {
+	c := make([]Choice, len(nvs))
+	for i, nv := range nvs {
+		c[i] = Choice{Name: nv.Name, Desc: nv.Desc}
+	}
+	return c
+}
+
 // File creates an option that stores a string filename.
 // This differs from String by accepting "-" as a positional argument.
 func File(name, desc string) *option[string] {
@@ -160,7 +221,7 @@ func FileLike[T ~string](name, desc string) *option[T] {
 // FileLikeVar creates an option that stores a string-like filename.
 // This differs from StringLike by accepting "-" as a positional argument.
 func FileLikeVar[T ~string](p *T, name, desc string) *option[T] {
-	return StringLikeVar[T](p, name, desc).withStrOK(dashOK)
+	return StringLikeVar[T](p, name, desc).withStrOK(dashOK).Complete(completeFiles)
 }
 
 var dashOK = []string{"-"}
@@ -234,10 +295,11 @@ func Parser[T any](name, desc string, parse func(string) (T, error)) *option[T]
 // Parser creates an option that converts with the provided parse function.
 func ParserVar[T any](p *T, name, desc string, parse func(string) (T, error)) *option[T] {
 	return &option[T]{
-		name:  name,
-		desc:  desc,
-		value: p,
-		parse: parse,
+		name:    name,
+		desc:    desc,
+		value:   p,
+		initial: *p,
+		parse:   parse,
 	}
 }
 
@@ -8,28 +8,47 @@ type options[T any] struct {
 	name     string
 	desc     string
 	value    *[]T
+	initial  []T // initial is restored before each parse
 	parse    func(string) (T, error)
 	prefixOK string   // set to - to allow -[^-]+, or -- to allow --.+ in arg context
 	strOK    []string // include unusual values such as - to allow them in arg context
+	named    []Choice // the names accepted by NamedSliceOf and similar
+	complete Completer
+	secret   bool // hide the values, as set by Sensitive
 	see      []*Command
+	src      Source
 }
 
 func (o *options[T]) description() string                    { return o.desc }
 func (o *options[T]) seeAlso() []*Command                    { return o.see }
 func (o *options[T]) setSeeAlso(cmds ...*Command)            { o.see = cmds }
+func (o *options[T]) source() Source                         { return o.src }
+func (o *options[T]) setSource(src Source)                   { o.src = src }
+func (o *options[T]) reset()                                 { o.src, *o.value = Source{}, slices.Clone(o.initial) }
 func (o *options[T]) parseDefault(arg string) error          { _, err := o.got([]string{arg}); return err }
 func (o *options[T]) parseValues(args []string) (int, error) { return o.got(args) }
 func (o *options[T]) okValues() []string                     { return o.strOK }
 func (o *options[T]) okPrefix() string                       { return o.prefixOK }
+func (o *options[T]) choices() []Choice                      { return o.named }
+func (o *options[T]) completer() Completer                   { return o.complete }
+func (o *options[T]) sensitive() bool                        { return o.secret }
 func (o *options[T]) withPrefixOK(ok string) *options[T]     { o.prefixOK = ok; return o }
 func (o *options[T]) withStrOK(ok []string) *options[T]      { o.strOK = ok; return o }
+func (o *options[T]) withChoices(c []Choice) *options[T]     { o.named = c; return o }
+
+// Complete sets a function that offers values for this option to shell completion; see CompletionCommand.
+// Without one, completion offers the names accepted by NamedSliceOf and similar, and file names for File options.
+func (o *options[T]) Complete(c Completer) *options[T] { o.complete = c; return o }
+
+// Sensitive hides this option's values from help, errors, Debug, and DumpConfig, and from prompts.
+func (o *options[T]) Sensitive() *options[T] { o.secret = true; return o }
 
 func (o *options[T]) got(args []string) (int, error) {
 	*o.value = make([]T, 0, len(args))
 	for i, arg := range args {
 		v, err := o.parse(arg)
 		if err != nil {
-			return i, err
+			return i, redactError(err, arg, o.secret)
 		}
 		*o.value = append(*o.value, v)
 	}
@@ -38,6 +57,12 @@ func (o *options[T]) got(args []string) (int, error) {
 
 func (o *options[T]) Value() []T { return *o.value }
 
+// Presence returns a Param that produces its value and whether the user provided it.
+// This can be used to distinguish provided values from defaults.
+func (o *options[T]) Presence() Param[Presence[[]T]] {
+	return presenceOf[[]T]{o.value, o}
+}
+
 // TODO: add FlagOn / FlagsOn, implemented as flags that split the string on a substring?
 
 // Args returns an multi-Arg definition for this option with a custom alias.
@@ -99,7 +124,7 @@ func NamedSliceOf[T any](name, desc string, mapping []NamedValue[T]) *options[T]
 // This is suitable for small to medium sets of names.
 func NamedSliceVarOf[T any](p *[]T, name, desc string, mapping []NamedValue[T]) *options[T] {
 	mapping = slices.Clone(mapping)
-	return ParserSliceVar(p, name, desc, (namedValues[T])(mapping).parse)
+	return ParserSliceVar(p, name, desc, (namedValues[T])(mapping).parse).withChoices((namedValues[T])(mapping).choices())
 }
 
 // FileSlice creates an option that stores a string slice of filenames.
@@ -129,7 +154,7 @@ func FileLikeSlice[T ~string](name, desc string) *options[T] {
 // This differs from StringLikeSlice by accepting "-" as a positional argument,
 // and from FileLike by supporting Rest().
 func FileLikeSliceVar[T ~string](p *[]T, name, desc string) *options[T] {
-	return StringLikeSliceVar[T](p, name, desc).withStrOK(dashOK)
+	return StringLikeSliceVar[T](p, name, desc).withStrOK(dashOK).Complete(completeFiles)
 }
 
 // IntSlice creates and option that stores a slice of int values.
@@ -213,9 +238,10 @@ func ParserSlice[T any](name, desc string, parse func(string) (T, error)) *optio
 // It converts strings by calling parse.
 func ParserSliceVar[T any](p *[]T, name, desc string, parse func(string) (T, error)) *options[T] {
 	return &options[T]{
-		name:  name,
-		desc:  desc,
-		value: p,
-		parse: parse,
+		name:    name,
+		desc:    desc,
+		value:   p,
+		initial: slices.Clone(*p),
+		parse:   parse,
 	}
 }
@@ -0,0 +1,138 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SetPrompting enables asking for missing arguments and required flags when Environ.Stdin is a terminal,
+// as reported by Environ.IsTerminal. Prompts are written to Environ.Stderr.
+//
+// Each answer is parsed as if given on the command line, and asked for again if it is rejected.
+// Options with choices offer them as a numbered menu, and sensitive options are read without echo.
+func (a *Application) SetPrompting(on bool) {
+	a.prompting = on
+}
+
+// prompter asks for values of missing options.
+type prompter struct {
+	app *Application
+	env Environ
+}
+
+// prompter returns a prompter for env, or nil if the application should not prompt.
+func (a *Application) prompter(env Environ) *prompter {
+	if !a.prompting || env.Stdin == nil || env.IsTerminal == nil || !env.IsTerminal(env.Stdin) {
+		return nil
+	}
+	return &prompter{app: a, env: env}
+}
+
+// arg asks for the value of arg of cmd.
+func (p *prompter) arg(cmd *Command, arg *Arg) error {
+	return p.ask("<"+arg.name+">", arg.option, func(s string) error {
+		var err error
+		switch parser := arg.option.(type) {
+		case valuesParser:
+			var words []string
+			if words, err = splitWords(s); err == nil {
+				_, err = parser.parseValues(words)
+			}
+		case valueParser:
+			err = parser.parseValue(s)
+		default:
+			return argError(KindBadArg, cmd, arg, s, -1, nil)
+		}
+		if err != nil {
+			return argError(KindArgValue, cmd, arg, s, -1, err)
+		}
+		return nil
+	})
+}
+
+// flag asks for the value of flag, reporting errors against cmd.
+func (p *prompter) flag(cmd *Command, flag *Flag) error {
+	return p.ask(flag.name(), flag.option, func(s string) error {
+		var err error
+		if parser, ok := flag.option.(valueParser); ok {
+			err = parser.parseValue(s)
+		} else {
+			err = flag.option.parseDefault(s)
+		}
+		if err != nil {
+			return flagValueError(cmd, flag, flag.name(), s, -1, err)
+		}
+		return nil
+	})
+}
+
+// ask prompts for a value of opt, labelled name, until parse accepts one.
+// A number picks from opt's choices. It returns any error reading Stdin, such as io.EOF.
+func (p *prompter) ask(name string, opt Option, parse func(string) error) error {
+	label := name
+	if desc := opt.description(); desc != "" {
+		label += " (" + desc + ")"
+	}
+	choices := opt.choices()
+	if len(choices) > 0 {
+		fmt.Fprintln(p.env.Stderr, label+":")
+		width := 0
+		for _, c := range choices {
+			width = max(width, displayWidth(c.Name))
+		}
+		for i, c := range choices {
+			fmt.Fprintln(p.env.Stderr, strings.TrimRight(fmt.Sprintf("  %d) %s   %s", i+1, padRight(c.Name, width), c.Desc), " "))
+		}
+		label = fmt.Sprintf("%s [1-%d]", name, len(choices))
+	}
+	for {
+		fmt.Fprint(p.env.Stderr, label+": ")
+		line, err := p.readLine(opt.sensitive())
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(choices) {
+			line = choices[n-1].Name
+		}
+		if err := parse(line); err != nil {
+			p.app.ferror(p.env.Stderr, err, p.app.colorTheme(p.env, p.env.Stderr))
+			continue
+		}
+		opt.setSource(Source{Kind: SourcePrompt})
+		return nil
+	}
+}
+
+// readLine reads a line from Stdin a byte at a time, so that input after it is left for the command.
+// If secret is set, the terminal does not echo it.
+func (p *prompter) readLine(secret bool) (string, error) {
+	if secret {
+		if restore, ok := noEcho(p.env.Stdin); ok {
+			defer fmt.Fprintln(p.env.Stderr)
+			defer restore()
+		}
+	}
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := p.env.Stdin.Read(b[:])
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"time"
 )
 
 var (
@@ -37,6 +39,17 @@ type Application struct {
 	Command
 
 	allowGroupShortFlags bool
+	exitCodes            func(error) int
+	errorFormat          func(io.Writer, error)
+	helpRenderer         HelpRenderer
+	signals              bool
+	grace                time.Duration
+	color                ColorMode
+	theme                *Theme
+	defaultsStyle        DefaultsStyle
+	completion           bool    // accept __complete, as added by CompletionCommand
+	prompting            bool    // ask for missing options, as set by SetPrompting
+	builtin              Command // builtin holds flags accepted by every command, such as --color.
 }
 
 func (a *Application) AllowGroupShortFlags(f bool) {
@@ -44,22 +57,46 @@ func (a *Application) AllowGroupShortFlags(f bool) {
 }
 
 func (a *Application) Ferror(w io.Writer, err error) {
-	fmt.Fprintf(w, "%s: error: %v\n", a.Name(), err)
+	a.ferror(w, err, nil)
+}
+
+// ferror implements Ferror, styling the prefix with t.
+func (a *Application) ferror(w io.Writer, err error, t *Theme) {
+	prefix := "error:"
+	if t != nil {
+		prefix = t.paint(t.Error, prefix)
+	}
+	fmt.Fprintf(w, "%s: %s %v\n", a.Name(), prefix, err)
 }
 
 // Main parses arguments and attemps to run the specified command handler.
-// If the command-line is invalid, it prints help for the selected command.
+// The handler is wrapped by any middleware added with Use, which in turn wraps any Before and After hooks.
+// Functions registered with Context.Defer run last, and their errors are joined with the handler's.
+//
+// If HandleSignals was called, the handler's Context is canceled by SIGINT or SIGTERM.
+//
+// Any resulting error is reported to env.Stderr, and carries an exit code for [Main].
+// See SetErrorFormatter and SetExitCodes.
 func (a *Application) Main(ctx context.Context, env Environ) error {
-	cmd, err := a.Parse(env)
-	switch err := err.(type) {
-	case nil:
-	case extraArgsError:
-		cmd := err.Command()
-		return errors.Join(err, cmd.PrintHelp(Context{ctx, env, cmd}, a))
-	case missingArgsError:
-		cmd := err.Command()
-		return errors.Join(err, cmd.PrintHelp(Context{ctx, env, cmd}, a))
-	default:
+	err := a.main(ctx, env)
+	if err != nil {
+		a.reportError(env, err)
+		if a.exitCodes != nil {
+			err = exitError{err, a.exitCodes(err)}
+		}
+	}
+	return err
+}
+
+func (a *Application) main(ctx context.Context, env Environ) error {
+	if a.signals {
+		var stop func()
+		ctx, stop = a.notifyContext(ctx, env)
+		defer stop()
+	}
+
+	cmd, err := a.parse(ctx, env)
+	if err != nil {
 		return err
 	}
 
@@ -67,11 +104,31 @@ func (a *Application) Main(ctx context.Context, env Environ) error {
 	if err != nil {
 		return err
 	}
-	return handler(Context{ctx, env, cmd})
+	if err := loadFiles(env, cmd); err != nil {
+		return err
+	}
+	hctx := Context{Context: ctx, Environ: env, Command: cmd, cleanups: new([]func() error)}
+	err = cmd.wrapHandler(cmd.hookHandler(handler))(hctx)
+	if err != nil {
+		err = handlerError{ec(cmd), interrupted(ctx, err)}
+	}
+	return errors.Join(err, hctx.cleanup())
 }
 
 // Parse attemps to parse arguments and returns the selected command.
+// It does not read the files named by FileFlags, which Main reads before running the handler.
 func (a *Application) Parse(env Environ) (*Command, error) {
+	return a.parse(context.Background(), env)
+}
+
+// parse implements Parse, using ctx for any computed defaults.
+func (a *Application) parse(ctx context.Context, env Environ) (*Command, error) {
+	return a.parseWith(ctx, env, nil)
+}
+
+// parseWith implements parse. If comp is non-nil, the last argument is being completed:
+// parseWith records where it falls in comp instead of parsing it, and skips over errors before it.
+func (a *Application) parseWith(ctx context.Context, env Environ, comp *completion) (*Command, error) {
 	if len(env.Args) < 1 {
 		return nil, wrap(ErrMissing, "program name")
 	}
@@ -79,19 +136,33 @@ func (a *Application) Parse(env Environ) (*Command, error) {
 	arg0 := env.Args[0]
 	_ = arg0
 
+	if a.completion && len(env.Args) > 1 && env.Args[1] == "__complete" {
+		return a.completeCommand(env.Args[2:]), nil
+	}
+
+	a.reset()
+	if err := applyDefaults(ctx, env, &a.Command, &a.builtin, nil); err != nil {
+		return nil, err
+	}
+
 	cur := &a.Command
 	canFlag := true
 	carg := 0
 	showHelp := false
+	showVersion, versionJSON := false, false
 
 	maybeFlag := func(arg string) bool { return strings.HasPrefix(arg, "--") || (len(arg) == 2 && arg[0] == '-') }
 	if a.allowGroupShortFlags {
 		maybeFlag = func(arg string) bool { return strings.HasPrefix(arg, "-") }
 	}
 
-nextArg:
+	last := len(env.Args) - 1
 	for i := 1; i < len(env.Args); {
 		arg := env.Args[i]
+		if comp != nil && i == last {
+			comp.locate(a, cur, carg, canFlag, arg)
+			break
+		}
 		if canFlag {
 			if arg == "--" {
 				canFlag = false
@@ -99,42 +170,23 @@ nextArg:
 				continue
 			}
 
-			for cmd := cur; cmd != nil; cmd = cmd.parent {
-				if idx, rem := cmd.lookupFlag(arg); idx >= 0 && canFlag {
-					opt := &cmd.flags[idx]
-					switch rem {
-					case 0: // --arg possibly with following val
-						switch parse := opt.option.(type) {
-						case flagParser: // --arg <ignored>
-							if err := parse.parseFlag(); err != nil {
-								return nil, flagParseError{ec(cmd), opt, arg, err}
-							}
-							i += 1
-						case valueParser: // --arg val
-							if i+1 >= len(env.Args) {
-								return nil, missingFlagValueError{ec(cmd), opt, arg}
-							}
-							if err := parse.parseValue(env.Args[i+1]); err != nil {
-								return nil, flagParseError{ec(cmd), opt, arg, err}
-							}
-							i += 2
-						default:
-							return nil, badFlagError{ec(cmd), opt, arg}
-						}
-					default: // --arg=val; rem points to v
-						switch parse := opt.option.(type) {
-						case inlineParser:
-							if err := parse.parseInline(arg[rem:]); err != nil {
-								return nil, flagParseError{ec(cmd), opt, arg, err}
-							}
-							i += 1
-						default:
-							return nil, extraFlagValueError{ec(cmd), arg}
-						}
-					}
-					opt.valueSet = true
-					continue nextArg
+			if owner, flag, rem := a.lookupFlag(cur, arg); flag != nil {
+				if comp != nil && rem == 0 && i+1 == last && takesValue(flag) {
+					comp.cur, comp.flag = cur, flag
+					break
+				}
+				next, err := parseFlag(owner, flag, env.Args, i, rem)
+				switch {
+				case err == nil:
+				case comp == nil:
+					return nil, err
+				case rem == 0 && takesValue(flag):
+					next = i + 2
+				default:
+					next = i + 1
 				}
+				i = next
+				continue
 			}
 
 			if !cur.noHelp && (arg == "-h" || arg == "--help") {
@@ -143,8 +195,23 @@ nextArg:
 				continue
 			}
 
+			if a.version != nil && (arg == "-V" || arg == "--version") {
+				showVersion = true
+				i++
+				continue
+			}
+			if showVersion && arg == "--json" {
+				versionJSON = true
+				i++
+				continue
+			}
+
 			if maybeFlag(arg) && (carg >= len(cur.args) || !cur.args[carg].can(arg)) {
-				return nil, extraFlagError{ec(cur), arg}
+				if comp != nil {
+					i++
+					continue
+				}
+				return nil, flagError(KindExtraFlag, cur, nil, arg, i, nil)
 			}
 		}
 
@@ -153,12 +220,20 @@ nextArg:
 			switch parser := opt.option.(type) {
 			case valuesParser:
 				args := env.Args[i:]
+				if comp != nil {
+					args = env.Args[i:last]
+				}
 				if !canFlag {
 					took, err := parser.parseValues(args)
 					if err != nil {
-						return nil, argParseError{ec(cur), opt, args[took], err}
+						if comp != nil {
+							i++
+							continue
+						}
+						return nil, argError(KindArgValue, cur, opt, args[took], i+took, err)
 					}
 					i += took
+					opt.option.setSource(Source{Kind: SourceCommandLine})
 				} else {
 					uncan := len(args) // track end of canFlag, to handle where processing ends
 					for i, arg := range args {
@@ -176,22 +251,35 @@ nextArg:
 					}
 					took, err := parser.parseValues(args)
 					if err != nil {
-						return nil, argParseError{ec(cur), opt, args[took], err}
+						if comp != nil {
+							i++
+							continue
+						}
+						at := i + took
+						if took >= uncan {
+							at++ // skip the removed --
+						}
+						return nil, argError(KindArgValue, cur, opt, args[took], at, err)
 					}
 					i += took
 					if took > uncan {
 						i++
 						canFlag = false
 					}
+					opt.option.setSource(Source{Kind: SourceCommandLine})
 				}
 
 			case valueParser:
-				if err := parser.parseValue(arg); err != nil {
-					return nil, argParseError{ec(cur), opt, arg, err}
+				if err := parser.parseValue(arg); err != nil && comp == nil {
+					return nil, argError(KindArgValue, cur, opt, arg, i, err)
 				}
+				opt.option.setSource(Source{Kind: SourceCommandLine})
 				i += 1
 			default:
-				return nil, badArgError{ec(cur), opt, arg}
+				return nil, argError(KindBadArg, cur, opt, arg, i, nil)
+			}
+			if comp != nil && i == last {
+				continue // the values may go on with the word being completed
 			}
 			carg++
 			continue
@@ -206,7 +294,30 @@ nextArg:
 			continue
 		}
 
-		return nil, extraArgsError{ec(cur), env.Args[i:]}
+		if comp != nil {
+			i++
+			continue
+		}
+
+		if cur == &a.Command && arg == "help" && a.offersHelpCommand() {
+			return a.helpFor(env.Args[i+1:], i+1)
+		}
+
+		return nil, extraArgsError(cur, env.Args[i:], i)
+	}
+	if comp != nil {
+		// completers see the defaults of options not yet given; errors are left to the command itself
+		for i := carg; i < len(cur.args); i++ {
+			if arg := &cur.args[i]; arg.defaultSet && arg.option.parseDefault(arg.defaultString) == nil {
+				arg.option.setSource(Source{Kind: SourceDefault})
+			}
+		}
+		for cmd := cur; cmd != nil; cmd = cmd.parent {
+			for f := range cmd.flags {
+				_ = applyDefault(ctx, env, cur, cmd, &cmd.flags[f], nil)
+			}
+		}
+		return cur, nil
 	}
 
 	if showHelp {
@@ -215,24 +326,224 @@ nextArg:
 		}
 		return helpCommand(a, cur), nil
 	}
+	if showVersion {
+		return versionCommand(a, versionJSON), nil
+	}
 
+	prompt := a.prompter(env)
 	if carg < len(cur.args) {
-		return nil, missingArgsError{ec(cur), cur.args[carg:]}
+		missing := cur.args[carg:]
+		if prompt == nil && slices.ContainsFunc(missing, func(arg Arg) bool { return !arg.defaultSet }) {
+			var args []*Arg
+			for i := range missing {
+				if !missing[i].defaultSet {
+					args = append(args, &missing[i])
+				}
+			}
+			return nil, missingArgsError(cur, args, nil)
+		}
+		for i := range missing {
+			arg := &missing[i]
+			if !arg.defaultSet {
+				if err := prompt.arg(cur, arg); err != nil {
+					var unanswered []*Arg
+					for j := i; j < len(missing); j++ {
+						if !missing[j].defaultSet {
+							unanswered = append(unanswered, &missing[j])
+						}
+					}
+					if errors.Is(err, io.EOF) {
+						err = nil
+					}
+					return nil, missingArgsError(cur, unanswered, err)
+				}
+				continue
+			}
+			if err := arg.option.parseDefault(arg.defaultString); err != nil {
+				return cur, argError(KindArgValue, cur, arg, arg.defaultString, -1, err)
+			}
+			arg.option.setSource(Source{Kind: SourceDefault})
+		}
+	}
+
+	if err := claimFiles(ctx, env, cur); err != nil {
+		return cur, err
+	}
+	for cmd := cur; cmd != nil; cmd = cmd.parent {
+		if err := applyDefaults(ctx, env, cur, cmd, prompt); err != nil {
+			return cur, err
+		}
+	}
+
+	return cur, nil
+}
+
+// reset clears what the previous parse recorded in the options of the application and its commands,
+// so that sources, required flags, and flags such as Enabler reflect only the next one.
+// Built-in flags override application settings, so they are reset too.
+func (a *Application) reset() {
+	var visit func(*Command)
+	visit = func(cmd *Command) {
+		for i := range cmd.flags {
+			cmd.flags[i].valueSet = false
+			if cmd.flags[i].option != nil {
+				cmd.flags[i].option.reset()
+			}
+		}
+		for i := range cmd.args {
+			cmd.args[i].option.reset()
+		}
+		for _, sub := range cmd.cmds {
+			visit(sub)
+		}
+	}
+	visit(&a.Command)
+	visit(&a.builtin)
+}
+
+// parseFlag parses the flag opt of cmd found at args[i], and returns the index of the next argument.
+// If rem is non-zero, the flag's value follows at args[i][rem:].
+func parseFlag(cmd *Command, opt *Flag, args []string, i, rem int) (int, error) {
+	arg := args[i]
+	switch rem {
+	case 0: // --arg possibly with following val
+		switch parse := opt.option.(type) {
+		case flagParser: // --arg <ignored>
+			if err := parse.parseFlag(); err != nil {
+				return i, flagError(KindFlagValue, cmd, opt, arg, i, err)
+			}
+			i += 1
+		case valueParser: // --arg val
+			if i+1 >= len(args) {
+				return i, flagError(KindMissingFlagValue, cmd, opt, arg, i, nil)
+			}
+			if err := parse.parseValue(args[i+1]); err != nil {
+				return i, flagValueError(cmd, opt, arg, args[i+1], i, err)
+			}
+			i += 2
+		default:
+			return i, flagError(KindBadFlag, cmd, opt, arg, i, nil)
+		}
+	default: // --arg=val; rem points to v
+		switch parse := opt.option.(type) {
+		case inlineParser:
+			if err := parse.parseInline(arg[rem:]); err != nil {
+				return i, flagValueError(cmd, opt, arg, arg[rem:], i, err)
+			}
+			i += 1
+		default:
+			return i, flagError(KindExtraFlagValue, cmd, opt, arg, i, nil)
+		}
+	}
+	opt.valueSet = true
+	opt.option.setSource(Source{Kind: SourceCommandLine})
+	return i, nil
+}
+
+// fileLoader is implemented by the options of FileFlag, which read another option's value from a file.
+// Parsing claims the other option for the file named, if any, and Main loads it.
+type fileLoader interface {
+	claim()
+	load(stdin io.Reader) error
+}
+
+// claimFiles applies the defaults of the FileFlags available to cur, and claims the options of those naming a file,
+// so that they count towards required flags without reading the files.
+func claimFiles(ctx context.Context, env Environ, cur *Command) error {
+	for _, fo := range fileFlags(cur) {
+		if err := applyDefault(ctx, env, cur, fo.owner, fo.flag, nil); err != nil {
+			return err
+		}
+		fo.flag.option.(fileLoader).claim()
+	}
+	return nil
+}
+
+// loadFiles reads the files claimed by the FileFlags available to cur.
+func loadFiles(env Environ, cur *Command) error {
+	for _, fo := range fileFlags(cur) {
+		if err := fo.flag.option.(fileLoader).load(env.Stdin); err != nil {
+			return flagError(KindFlagValue, cur, fo.flag, fo.flag.name(), -1, err)
+		}
 	}
+	return nil
+}
 
+// fileFlags returns the FileFlags available to cur, and the commands that declare them.
+func fileFlags(cur *Command) []ownedFlag {
+	var files []ownedFlag
 	for cmd := cur; cmd != nil; cmd = cmd.parent {
 		for f := range cmd.flags {
 			flag := &cmd.flags[f]
-			if flag.defaultSet && !flag.valueSet {
-				err := flag.option.parseDefault(flag.defaultString)
-				if err != nil {
-					return cur, flagParseError{ec(cur), flag, flag.defaultString, err}
-				}
+			if _, ok := flag.option.(fileLoader); ok && (cmd == cur || flag.persistent) {
+				files = append(files, ownedFlag{cmd, flag})
 			}
 		}
 	}
+	return files
+}
 
-	return cur, nil
+// ownedFlag is a flag and the command that declares it.
+type ownedFlag struct {
+	owner *Command
+	flag  *Flag
+}
+
+// applyDefaults sets each flag of owner not given on the command line from its environment variable or default.
+// Required flags available to cur that remain unset are asked for with prompt, if not nil.
+// Errors, including for required flags still unset, are reported against cur, the selected command.
+func applyDefaults(ctx context.Context, env Environ, cur, owner *Command, prompt *prompter) error {
+	for f := range owner.flags {
+		if _, ok := owner.flags[f].option.(fileLoader); ok {
+			continue // applied by claimFiles
+		}
+		if err := applyDefault(ctx, env, cur, owner, &owner.flags[f], prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDefault sets flag of owner, if not given on the command line or read by a FileFlag, as described by applyDefaults.
+func applyDefault(ctx context.Context, env Environ, cur, owner *Command, flag *Flag, prompt *prompter) error {
+	if flag.valueSet || flag.option.source().Kind == SourceFile {
+		return nil
+	}
+	if val, ok := lookupEnv(env, flag.env); ok {
+		if err := flag.option.parseDefault(val); err != nil {
+			return flagValueError(cur, flag, "$"+flag.env, val, -1, err)
+		}
+		flag.option.setSource(EnvSource(flag.env))
+		return nil
+	}
+	switch {
+	case flag.defaultFunc != nil:
+		def, err := flag.defaultFunc(Context{Context: ctx, Environ: env, Command: cur})
+		if err != nil {
+			return flagError(KindFlagValue, cur, flag, flag.name(), -1, err)
+		}
+		if err := flag.option.parseDefault(def); err != nil {
+			return flagValueError(cur, flag, def, def, -1, err)
+		}
+		flag.option.setSource(Source{Kind: SourceDefault})
+	case flag.defaultSet:
+		err := flag.option.parseDefault(flag.defaultString)
+		if err != nil {
+			return flagValueError(cur, flag, flag.defaultString, flag.defaultString, -1, err)
+		}
+		flag.option.setSource(Source{Kind: SourceDefault})
+	case flag.required && (owner == cur || flag.persistent):
+		if prompt == nil {
+			return flagError(KindMissingFlag, cur, flag, flag.name(), -1, nil)
+		}
+		if err := prompt.flag(cur, flag); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return flagError(KindMissingFlag, cur, flag, flag.name(), -1, err)
+		}
+	}
+	return nil
 }
 
 // options should implement one or more of the following to indicate what they accept.
@@ -249,6 +560,15 @@ type (
 	valuesParser interface{ parseValues([]string) (int, error) }
 )
 
+// lookupEnv returns the non-empty value of the environment variable name, if any.
+func lookupEnv(env Environ, name string) (string, bool) {
+	if name == "" || env.LookupEnv == nil {
+		return "", false
+	}
+	val, ok := env.LookupEnv(name)
+	return val, ok && val != ""
+}
+
 func wrap(e error, m string) error {
 	if e == nil {
 		return e
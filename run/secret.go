@@ -0,0 +1,132 @@
+package run
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces sensitive values wherever they would be shown.
+const redacted = "***"
+
+// Secret creates a sensitive option that stores a string, such as a password or token.
+// Its value is hidden from help, errors, Debug, and DumpConfig, and read without echo when prompted for.
+// To keep it out of the command line, read it with Flag.Env, or with FileFlag from a file or stdin.
+func Secret(name, desc string) *option[string] {
+	var v string
+	return SecretVar(&v, name, desc)
+}
+
+// SecretVar creates a sensitive option that stores a string, such as a password or token.
+// Its value is hidden from help, errors, Debug, and DumpConfig, and read without echo when prompted for.
+// To keep it out of the command line, read it with Flag.Env, or with FileFlag from a file or stdin.
+func SecretVar(p *string, name, desc string) *option[string] {
+	return StringVar(p, name, desc).Sensitive()
+}
+
+// FileFlag returns a flag that sets this option from the contents of a file, such as --token-file, or from Stdin given "-".
+// A single trailing newline is removed. The file is read by Application.Main before running the handler, not by Parse.
+// It takes precedence over this option's own flag, and naming one satisfies it if Required.
+func (o *option[T]) FileFlag(long string) Flag {
+	return Flag{option: &fileOf[T]{option: o, name: long}, string: long, hint: "<file>"}
+}
+
+// fileOf is the option of a FileFlag, naming a file to read the value of option from.
+type fileOf[T any] struct {
+	option *option[T]
+	name   string
+	path   string
+	see    []*Command
+	src    Source
+}
+
+func (f *fileOf[T]) description() string {
+	return strings.TrimSpace(f.option.desc + " from a file, or - for stdin")
+}
+func (f *fileOf[T]) seeAlso() []*Command            { return f.see }
+func (f *fileOf[T]) setSeeAlso(cmds ...*Command)    { f.see = cmds }
+func (f *fileOf[T]) source() Source                 { return f.src }
+func (f *fileOf[T]) setSource(src Source)           { f.src = src }
+func (f *fileOf[T]) reset()                         { f.src, f.path = Source{}, "" }
+func (f *fileOf[T]) okValues() []string             { return dashOK }
+func (f *fileOf[T]) okPrefix() string               { return "" }
+func (f *fileOf[T]) choices() []Choice              { return nil }
+func (f *fileOf[T]) completer() Completer           { return completeFiles }
+func (f *fileOf[T]) sensitive() bool                { return false }
+func (f *fileOf[T]) parseDefault(path string) error { f.path = path; return nil }
+func (f *fileOf[T]) parseInline(path string) error  { f.path = path; return nil }
+func (f *fileOf[T]) parseValue(path string) error   { f.path = path; return nil }
+func (f *fileOf[T]) debug() string                  { return f.name + "=" + f.path }
+
+// claim records that the option is to be set from the file named in this parse, if any.
+func (f *fileOf[T]) claim() {
+	if f.path != "" {
+		f.option.setSource(Source{Kind: SourceFile, Name: f.path})
+	}
+}
+
+// load sets the option from the file named in this parse, if any, reading "-" from stdin.
+func (f *fileOf[T]) load(stdin io.Reader) error {
+	if f.path == "" {
+		return nil
+	}
+	var data []byte
+	var err error
+	if f.path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(f.path)
+	}
+	if err != nil {
+		return err
+	}
+	s := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if err := f.option.got(s, true); err != nil {
+		return err
+	}
+	f.option.setSource(Source{Kind: SourceFile, Name: f.path})
+	return nil
+}
+
+// redact returns redacted in place of s if it is sensitive and not empty.
+func redact(s string, sensitive bool) string {
+	if sensitive && s != "" {
+		return redacted
+	}
+	return s
+}
+
+// redactToken hides the value in token, a flag's argument or default, leaving any flag name or environment variable.
+func redactToken(token string) string {
+	if name, _, ok := strings.Cut(token, "="); ok && strings.HasPrefix(token, "-") {
+		return name + "=" + redacted
+	}
+	if strings.HasPrefix(token, "-") || strings.HasPrefix(token, "$") {
+		return token
+	}
+	return redacted
+}
+
+// redactError hides value in the message of err, if it is sensitive.
+func redactError(err error, value string, sensitive bool) error {
+	if !sensitive || value == "" {
+		return err
+	}
+	return redactedError{err, value}
+}
+
+type redactedError struct {
+	err   error
+	value string
+}
+
+// Error replaces the value where err quotes it. If the value still shows, the cause is withheld.
+func (e redactedError) Error() string {
+	msg := strings.ReplaceAll(e.err.Error(), strconv.Quote(e.value), strconv.Quote(redacted))
+	if strings.Contains(msg, e.value) {
+		return "invalid value"
+	}
+	return msg
+}
+func (e redactedError) Unwrap() error { return e.err }
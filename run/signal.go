@@ -0,0 +1,86 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// ExitForced is the exit code used when a second signal, or the grace period, ends a handler that did not stop.
+const ExitForced = 137
+
+// HandleSignals makes Main cancel the handler's Context on the first SIGINT or SIGTERM.
+// If the handler has not returned after a second signal, or after grace if it is positive,
+// Main exits the process with ExitForced through Environ.Exit.
+func (a *Application) HandleSignals(grace time.Duration) {
+	a.signals = true
+	a.grace = grace
+}
+
+// InterruptedError is the cause of a Context canceled by a signal.
+// Main returns it in place of a handler's [context.Canceled] error.
+type InterruptedError struct {
+	Signal os.Signal
+}
+
+func (e InterruptedError) Error() string {
+	return "interrupted: " + e.Signal.String()
+}
+
+// ExitCode follows the shell convention of 128 plus the signal number.
+func (e InterruptedError) ExitCode() int {
+	return 128 + signalNumber(e.Signal)
+}
+
+// notifyContext returns a context canceled by the first signal, and a func that stops relaying signals.
+func (a *Application) notifyContext(ctx context.Context, env Environ) (context.Context, func()) {
+	notify, exit := env.Signals, env.Exit
+	if notify == nil {
+		notify = notifySignals
+	}
+	if exit == nil {
+		exit = os.Exit
+	}
+
+	sigs, stop := notify(shutdownSignals...)
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigs:
+			cancel(InterruptedError{sig})
+		case <-done:
+			return
+		}
+
+		var timeout <-chan time.Time
+		if a.grace > 0 {
+			timer := time.NewTimer(a.grace)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-sigs:
+			exit(ExitForced)
+		case <-timeout:
+			exit(ExitForced)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		stop()
+		cancel(nil)
+	}
+}
+
+// interrupted replaces a cancellation error with the signal that caused it.
+func interrupted(ctx context.Context, err error) error {
+	var cause InterruptedError
+	if errors.Is(err, context.Canceled) && errors.As(context.Cause(ctx), &cause) {
+		return cause
+	}
+	return err
+}
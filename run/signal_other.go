@@ -0,0 +1,19 @@
+//go:build !plan9
+
+package run
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals are the signals HandleSignals relays: SIGINT and SIGTERM.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// signalNumber returns the number of sig, or that of SIGINT if it has none.
+func signalNumber(sig os.Signal) int {
+	if sig, ok := sig.(syscall.Signal); ok {
+		return int(sig)
+	}
+	return int(syscall.SIGINT)
+}
@@ -0,0 +1,9 @@
+package run
+
+import "os"
+
+// shutdownSignals are the signals HandleSignals relays: the interrupt note.
+var shutdownSignals = []os.Signal{os.Interrupt}
+
+// signalNumber returns 2, the number of SIGINT, as notes have no numbers.
+func signalNumber(os.Signal) int { return 2 }
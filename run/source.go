@@ -0,0 +1,99 @@
+package run
+
+// SourceKind identifies where an option's value came from.
+type SourceKind int
+
+const (
+	SourceUnset       SourceKind = iota // the option was not provided, and has no default
+	SourceDefault                       // the option was supplied by Default or DefaultFunc
+	SourceEnv                           // the option was supplied by an environment variable
+	SourceCommandLine                   // the option was provided on the command line
+	SourcePrompt                        // the option was answered at a prompt; see SetPrompting
+	SourceFile                          // the option was read from a file named by a FileFlag
+	SourceConfig                        // the option was read from a configuration file; see ConfigSource
+)
+
+// Source describes where an option's value came from.
+type Source struct {
+	Kind SourceKind
+	Name string // Name is the environment variable for SourceEnv, or the file for SourceFile and SourceConfig.
+	Key  string // Key is the setting within the file for SourceConfig.
+}
+
+// EnvSource returns a Source for the environment variable name.
+func EnvSource(name string) Source {
+	return Source{Kind: SourceEnv, Name: name}
+}
+
+// ConfigSource returns a Source for the setting key in the configuration file path. See Context.SetFrom.
+func ConfigSource(path, key string) Source {
+	return Source{Kind: SourceConfig, Name: path, Key: key}
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceDefault:
+		return "default"
+	case SourceEnv:
+		return "env $" + s.Name
+	case SourceCommandLine:
+		return "command line"
+	case SourcePrompt:
+		return "prompt"
+	case SourceFile:
+		if s.Name == "-" {
+			return "stdin"
+		}
+		return "file " + s.Name
+	case SourceConfig:
+		if s.Key == "" {
+			return "config " + s.Name
+		}
+		return "config " + s.Name + " [" + s.Key + "]"
+	default:
+		return "unset"
+	}
+}
+
+// explicit reports whether the source represents a value the user provided.
+func (s Source) explicit() bool {
+	return s.Kind == SourceEnv || s.Kind == SourceCommandLine || s.Kind == SourcePrompt || s.Kind == SourceFile || s.Kind == SourceConfig
+}
+
+// Source reports where the value of opt came from in the most recent parse.
+func (c Context) Source(opt Option) Source {
+	return opt.source()
+}
+
+// SetFrom sets opt to value, parsed like a default, and records src as where it came from.
+// Use it to apply settings from elsewhere, such as ConfigSource, to options the user did not set:
+//
+//	if !ctx.IsSet(level) {
+//		err = ctx.SetFrom(level, cfg.Level, run.ConfigSource(path, "level"))
+//	}
+func (c Context) SetFrom(opt Option, value string, src Source) error {
+	if err := opt.parseDefault(value); err != nil {
+		return err
+	}
+	opt.setSource(src)
+	return nil
+}
+
+// IsSet reports whether the user provided opt, such as on the command line, in the environment, or in a configuration file.
+// Options holding a default or zero value are not set.
+func (c Context) IsSet(opt Option) bool {
+	return opt.source().explicit()
+}
+
+// Presence pairs an option's value with whether the user provided it.
+type Presence[T any] struct {
+	Value T
+	Set   bool
+}
+
+type presenceOf[T any] struct {
+	value *T
+	opt   Option
+}
+
+func (p presenceOf[T]) Value() Presence[T] { return Presence[T]{*p.value, p.opt.source().explicit()} }
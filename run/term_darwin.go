@@ -0,0 +1,5 @@
+package run
+
+import "syscall"
+
+const ioctlGetTermios, ioctlSetTermios = syscall.TIOCGETA, syscall.TIOCSETA
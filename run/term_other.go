@@ -0,0 +1,17 @@
+//go:build !(linux || darwin)
+
+package run
+
+import (
+	"io"
+	"os"
+)
+
+// terminalWidth returns 0, as terminal widths are not detected on this platform.
+func terminalWidth(io.Writer) int { return 0 }
+
+// noEcho reports false, as turning off terminal echo is not supported on this platform.
+func noEcho(any) (restore func(), ok bool) { return nil, false }
+
+// fileIsTerminal reports that terminals are not detected on this platform.
+func fileIsTerminal(*os.File) (tty, known bool) { return false, false }
@@ -0,0 +1,50 @@
+//go:build linux || darwin
+
+package run
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns the width of the terminal w writes to, or 0 if it is not a terminal.
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.col)
+}
+
+// noEcho turns off echo on the terminal r reads from, and returns a func to restore it.
+// It reports false if r is not a terminal.
+func noEcho(r any) (restore func(), ok bool) {
+	f, ok := r.(*os.File)
+	if !ok {
+		return nil, false
+	}
+	var t syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return nil, false
+	}
+	quiet := t
+	quiet.Lflag &^= syscall.ECHO
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&quiet))); errno != 0 {
+		return nil, false
+	}
+	return func() { syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&t))) }, true
+}
+
+// fileIsTerminal reports whether f is a terminal, rather than another character device such as /dev/null.
+func fileIsTerminal(f *os.File) (tty, known bool) {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&t)))
+	return errno == 0, true
+}
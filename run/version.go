@@ -0,0 +1,104 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+// VersionInfo describes a build of an application, as printed by --version.
+type VersionInfo struct {
+	Version    string `json:"version,omitempty"`    // Version is the module version, such as v1.2.3.
+	Revision   string `json:"revision,omitempty"`   // Revision is the VCS revision the build is from.
+	Dirty      bool   `json:"dirty,omitempty"`      // Dirty is set if the build has uncommitted changes.
+	CommitTime string `json:"commitTime,omitempty"` // CommitTime is when the revision was committed, in RFC 3339 format.
+	GoVersion  string `json:"goVersion,omitempty"`  // GoVersion is the version of Go the build used.
+}
+
+// BuildVersion returns the VersionInfo recorded in the running binary by the go command.
+// The go command does not record when a binary was built, so the commit time of its revision stands in for it.
+func BuildVersion() VersionInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return VersionInfo{}
+	}
+	v := VersionInfo{GoVersion: info.GoVersion}
+	if info.Main.Version != "(devel)" {
+		v.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.time":
+			v.CommitTime = s.Value
+		case "vcs.modified":
+			v.Dirty = s.Value == "true"
+		}
+	}
+	return v
+}
+
+// String formats v as its version followed by any other details, as in "v1.2.3 (abc123def456, dirty, go1.22.0)".
+func (v VersionInfo) String() string {
+	s := v.Version
+	if s == "" {
+		s = "(devel)"
+	}
+	var details []string
+	if v.Revision != "" {
+		details = append(details, v.Revision[:min(len(v.Revision), 12)])
+	}
+	if v.Dirty {
+		details = append(details, "dirty")
+	}
+	if v.CommitTime != "" {
+		details = append(details, "committed "+v.CommitTime)
+	}
+	if v.GoVersion != "" {
+		details = append(details, v.GoVersion)
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// Version adds -V and --version flags to an application, which print info instead of running a command.
+// Following it with --json prints info as JSON. See also VersionCommand, and BuildVersion to describe the running binary.
+// It applies only to an application: Cmd reports an error if it is given to a command.
+func Version(info VersionInfo) CmdOption {
+	return cmdOptionFunc(func(cmd *Command) error {
+		cmd.version = &info
+		return nil
+	})
+}
+
+// VersionCommand returns a command that prints info, or with --json, prints it as JSON.
+// Add it to app with SetCommands, and run it as "app version".
+func VersionCommand(app *Application, info VersionInfo) *Command {
+	asJSON := Enabler("json", "Print as JSON", false, true)
+	return MustCmd("version", "Show version information",
+		asJSON.Flag(),
+		Handler1(func(ctx Context, asJSON bool) error { return printVersion(ctx.Stdout, app, info, asJSON) }, asJSON),
+	)
+}
+
+// versionFlag describes the -V, --version flag added by Version.
+var versionFlag = Flag{rune: 'V', string: "version"}
+
+func versionCommand(a *Application, asJSON bool) *Command {
+	return &Command{name: a.name + ".--version", about: &a.Command, handler: func(ctx Context) error {
+		return printVersion(ctx.Stdout, a, *a.version, asJSON)
+	}}
+}
+
+func printVersion(w io.Writer, app *Application, info VersionInfo, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(info)
+	}
+	_, err := fmt.Fprintln(w, app.name, info)
+	return err
+}
@@ -0,0 +1,110 @@
+package run
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultWidth is used for help when the terminal width is unknown.
+const defaultWidth = 80
+
+// helpWidth returns the width to wrap help written to w:
+// that reported by env.TerminalWidth, or else $COLUMNS, or else 80.
+func helpWidth(env Environ, w io.Writer) int {
+	if env.TerminalWidth != nil {
+		if width := env.TerminalWidth(w); width > 0 {
+			return width
+		}
+	}
+	if env.Getenv != nil {
+		if width, err := strconv.Atoi(env.Getenv("COLUMNS")); err == nil && width > 0 {
+			return width
+		}
+	}
+	return defaultWidth
+}
+
+// displayWidth returns the number of terminal columns s occupies.
+// Combining and other zero-width characters and ANSI escape sequences take none,
+// and East Asian wide characters take two.
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "\x1b[") {
+			end := strings.IndexFunc(s[i+2:], func(r rune) bool { return r >= '@' && r <= '~' })
+			if end >= 0 {
+				i += 2 + end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += runeWidth(r)
+		i += size
+	}
+	return width
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0x1160 && r <= 0x11FF):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRanges lists the East Asian wide and fullwidth ranges, and common emoji.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF}, {0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF},
+	{0xFE10, 0xFE19}, {0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F}, {0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func isWide(r rune) bool {
+	for _, rng := range wideRanges {
+		if r < rng[0] {
+			return false
+		}
+		if r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// padRight pads s with spaces to occupy width columns.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-displayWidth(s)))
+}
+
+// wrapText splits s into lines of at most width columns, breaking at spaces.
+// Words longer than width are left on their own line, and leading spaces are kept on the first.
+func wrapText(s string, width int) []string {
+	if width <= 0 || displayWidth(s) <= width {
+		return []string{s}
+	}
+	var lines []string
+	line := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	lineWidth, empty := len(line), true
+	for _, word := range strings.Fields(s) {
+		ww := displayWidth(word)
+		switch {
+		case empty:
+			line, lineWidth, empty = line+word, lineWidth+ww, false
+		case lineWidth+1+ww <= width:
+			line, lineWidth = line+" "+word, lineWidth+1+ww
+		default:
+			lines = append(lines, line)
+			line, lineWidth = word, ww
+		}
+	}
+	return append(lines, line)
+}